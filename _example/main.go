@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -17,36 +18,34 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	ch := make(chan sjson.Token)
+	errc := make(chan error, 1)
 	go func() {
-		defer close(ch)
-		err = sjson.ReadAll(f, ch)
-		if err != nil {
-			panic(err)
-		}
+		errc <- sjson.ReadAllContext(ctx, f, ch, sjson.CloseChannel())
 	}()
 
 	var offset int
-	for {
-		select {
-		case t, more := <-ch:
-			if !more {
-				return
-			}
-
-			if t.Type() == sjson.EndType {
-				offset--
-			}
-			for i := 0; i <= offset; i++ {
-				fmt.Printf(" ")
-			}
-			fmt.Printf("%s\n", t)
-
-			if t.Type() == 5 || t.Type() == 6 {
-				offset++
-			}
+	for t := range ch {
+		if t.Type() == sjson.EndType {
+			offset--
+		}
+		for i := 0; i <= offset; i++ {
+			fmt.Printf(" ")
+		}
+		fmt.Printf("%s\n", t)
 
+		if t.Type() == sjson.ObjectType || t.Type() == sjson.ArrayType {
+			offset++
 		}
 	}
+
+	if err := <-errc; err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
 }
@@ -1,25 +1,58 @@
 package sjson
 
-import "bufio"
+import (
+	"errors"
+	"io"
+)
 
+// An arrayToken reads an array through the frame of its level of nesting,
+// which holds its position and path
 type arrayToken struct {
-	r   *bufio.Reader
-	dec *Decoder
+	*frame
 }
 
-func (a *arrayToken) Type() Type {
+func (a arrayToken) Type() Type {
 	return ArrayType
 }
 
-func (a *arrayToken) Next() (Token, error) {
-	if a.dec == nil {
-		a.dec = newDecoder(a.r)
-		a.dec.initialState = a.dec.arrayState
+func (a arrayToken) Next() (Token, error) {
+	if a.done {
+		return nil, io.EOF
 	}
+	a.started = true
 
-	return a.dec.Next()
+	t, err := a.next()
+	if err == nil && t.Type() == EndType {
+		a.done = true
+	}
+
+	return t, err
+}
+
+// Skip consumes the rest of the array without decoding it
+func (a arrayToken) Skip() error {
+	if a.done {
+		return nil
+	}
+
+	a.done = true
+	return a.annotate(a.r.skipTo(a.depth - 1))
+}
+
+// Raw consumes the array and returns its exact bytes. It must be
+// called before any elements are read.
+func (a arrayToken) Raw() ([]byte, error) {
+	if a.done || a.started {
+		return nil, errors.New("Raw called on a partially read array")
+	}
+
+	a.done = true
+	raw, err := a.r.captureRaw("[", func() error {
+		return a.r.skipTo(a.depth - 1)
+	})
+	return raw, a.annotate(err)
 }
 
-func (a *arrayToken) String() string {
+func (a arrayToken) String() string {
 	return "arrayToken"
 }
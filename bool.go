@@ -2,23 +2,30 @@ package sjson
 
 import "reflect"
 
-type boolToken string
+type boolToken struct {
+	*scalar
+}
 
 func (bt boolToken) Type() Type {
 	return BoolType
 }
 
 func (bt boolToken) Unmarshal(b interface{}) error {
-	switch string(bt) {
-	case "true":
-		reflect.Indirect(reflect.ValueOf(b)).SetBool(true)
-	case "false":
-		reflect.Indirect(reflect.ValueOf(b)).SetBool(false)
+	v, err := bt.unmarshalTarget(b, "bool")
+	if err != nil {
+		return err
+	}
+	if ok, err := unmarshaler(bt, v); ok {
+		return err
+	}
+	if v.Kind() != reflect.Bool {
+		return bt.typeError("bool", v.Type())
 	}
 
+	v.SetBool(bt.text[0] == 't')
 	return nil
 }
 
 func (bt boolToken) String() string {
-	return string(bt)
+	return string(bt.text)
 }
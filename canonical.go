@@ -0,0 +1,97 @@
+package sjson
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Canonicalize reads one JSON value from r and writes its canonical
+// form (RFC 8785, the JSON Canonicalization Scheme) to w: without
+// whitespace, with the members of objects sorted by the UTF-16 code
+// units of their keys, numbers serialized as ECMAScript does and
+// strings with minimal escaping. Nothing is written after the value.
+//
+// Objects are held in memory to be sorted; arrays are streamed. Numbers
+// that are not finite double precision values are rejected with
+// ErrUnsupportedValue, and the input must hold exactly one value.
+func Canonicalize(w io.Writer, r io.Reader) error {
+	f := &formatter{canonical: true, opts: DecoderOptions{Mode: Strict}}
+	return f.format(w, r, nil)
+}
+
+// canonicalNumber returns the JCS serialization of the number text
+func canonicalNumber(tok Token, text string) (string, error) {
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("%w: %s is not a finite double at %s", ErrUnsupportedValue, text, tok.Position())
+	}
+	return formatES(f), nil
+}
+
+// formatES formats f the way ECMAScript's Number.prototype.toString
+// does, which is the shortest representation that round-trips
+func formatES(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+
+	var sign string
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// the shortest digits d1.d2d3...e±x, so the value is 0.d1d2d3... * 10^n
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	mant, exp, _ := strings.Cut(s, "e")
+	digits := strings.Replace(mant, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+	k, n := len(digits), x+1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	if k > 1 {
+		digits = digits[:1] + "." + digits[1:]
+	}
+	if n < 1 {
+		return sign + digits + "e-" + strconv.Itoa(1-n)
+	}
+	return sign + digits + "e+" + strconv.Itoa(n-1)
+}
+
+// lessUTF16 compares a and b by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	for len(a) > 0 && len(b) > 0 {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			// runes outside the BMP begin with a high surrogate, which
+			// sorts below U+E000 to U+FFFF
+			ua, ub := firstUnit(ra), firstUnit(rb)
+			if ua != ub {
+				return ua < ub
+			}
+			return ra < rb
+		}
+		a, b = a[sa:], b[sb:]
+	}
+	return len(a) == 0 && len(b) > 0
+}
+
+// firstUnit returns the first UTF-16 code unit of r
+func firstUnit(r rune) rune {
+	if r < 0x10000 {
+		return r
+	}
+	return 0xD800 + (r-0x10000)>>10
+}
@@ -0,0 +1,110 @@
+package sjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCanonicalize(t *testing.T) {
+
+	canonicalize := func(in string) (string, error) {
+		var buf bytes.Buffer
+		err := Canonicalize(&buf, strings.NewReader(in))
+		return buf.String(), err
+	}
+
+	Convey("The RFC 8785 example should be canonicalized", t, func() {
+		out, err := canonicalize(`{
+			"numbers": [333333333.33333329, 1E30, 4.50,
+						2e-3, 0.000000000000000000000000001],
+			"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			"literals": [null, true, false]
+		}`)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`)
+	})
+
+	Convey("Keys should be sorted by UTF-16 code units", t, func() {
+		out, err := canonicalize(`{
+			"€": "Euro Sign",
+			"\r": "Carriage Return",
+			"דּ": "Hebrew Letter Dalet With Dagesh",
+			"1": "One",
+			"😀": "Emoji: Grinning Face",
+			"\u0080": "Control",
+			"ö": "Latin Small Letter O With Diaeresis"
+		}`)
+		So(err, ShouldBeNil)
+
+		var values []string
+		dec := NewDecoder(strings.NewReader(out))
+		tok, err := dec.Next()
+		So(err, ShouldBeNil)
+		for _, v := range tok.(ObjectToken).Members() {
+			values = append(values, toText(v))
+		}
+		So(values, ShouldResemble, []string{
+			"Carriage Return",
+			"One",
+			"Control",
+			"Latin Small Letter O With Diaeresis",
+			"Euro Sign",
+			"Emoji: Grinning Face",
+			"Hebrew Letter Dalet With Dagesh",
+		})
+	})
+
+	Convey("Nested objects should be sorted and whitespace removed", t, func() {
+		out, err := canonicalize(" {\"b\": [{\"z\": 1, \"y\": {}}, []], \"a\": -0.0, \"aa\": 1e2}\n")
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"a":0,"aa":100,"b":[{"y":{},"z":1},[]]}`)
+	})
+
+	Convey("Numbers should match the RFC 8785 test vectors", t, func() {
+		for bits, want := range map[uint64]string{
+			0x0000000000000000: "0",
+			0x8000000000000000: "0",
+			0x0000000000000001: "5e-324",
+			0x8000000000000001: "-5e-324",
+			0x7fefffffffffffff: "1.7976931348623157e+308",
+			0xffefffffffffffff: "-1.7976931348623157e+308",
+			0x4340000000000000: "9007199254740992",
+			0xc340000000000000: "-9007199254740992",
+			0x4430000000000000: "295147905179352830000",
+			0x44b52d02c7e14af5: "9.999999999999997e+22",
+			0x44b52d02c7e14af6: "1e+23",
+			0x44b52d02c7e14af7: "1.0000000000000001e+23",
+			0x444b1ae4d6e2ef4e: "999999999999999700000",
+			0x444b1ae4d6e2ef4f: "999999999999999900000",
+			0x444b1ae4d6e2ef50: "1e+21",
+			0x3eb0c6f7a0b5ed8c: "9.999999999999997e-7",
+			0x3eb0c6f7a0b5ed8d: "0.000001",
+			0x41b3de4355555553: "333333333.3333332",
+			0x41b3de4355555554: "333333333.33333325",
+			0x41b3de4355555555: "333333333.3333333",
+			0x41b3de4355555556: "333333333.3333334",
+			0x41b3de4355555557: "333333333.33333343",
+			0xbecbf647612f3696: "-0.0000033333333333333333",
+			0x43143ff3c1cb0959: "1424953923781206.2",
+		} {
+			So(formatES(math.Float64frombits(bits)), ShouldEqual, want)
+		}
+	})
+
+	Convey("Invalid input should be rejected", t, func() {
+		_, err := canonicalize(`[1e400]`)
+		So(errors.Is(err, ErrUnsupportedValue), ShouldBeTrue)
+
+		_, err = canonicalize(`{} {}`)
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+
+		_, err = canonicalize(`  `)
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sheenobu/sjson"
+)
+
+func fmtCommand(e *env, args []string) error {
+	fs := e.flags("fmt", "[-c] [-indent string] [-sort size] [-width n] [-syntax syntax] [file...]")
+	compact := fs.Bool("c", false, "compact the output instead of indenting it")
+	indent := fs.String("indent", "  ", "indent each level with `string`")
+	sortSize := fs.Int("sort", 0, "sort the keys of objects of up to `size` bytes")
+	width := fs.Int("width", 0, "write arrays of simple values on one line if it fits in `n` bytes")
+	options := syntaxFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	formatOpts := []sjson.FormatOption{sjson.FormatDecoderOptions(opts)}
+	if *sortSize > 0 {
+		formatOpts = append(formatOpts, sjson.SortKeys(*sortSize))
+	}
+	if *width > 0 {
+		formatOpts = append(formatOpts, sjson.MaxWidth(*width))
+	}
+
+	return e.eachInput(fs.Args(), func(name string, r io.Reader) error {
+		var err error
+		if *compact {
+			err = sjson.Compact(e.stdout, r, formatOpts...)
+		} else {
+			err = sjson.Indent(e.stdout, r, "", *indent, formatOpts...)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sheenobu/sjson"
+)
+
+func linesCommand(e *env, args []string) error {
+	fs := e.flags("lines", "[-split | -join [-skip]] [-syntax syntax] [file...]")
+	split := fs.Bool("split", false, "write each element of top-level arrays as its own line")
+	join := fs.Bool("join", false, "read newline-delimited JSON and write its values as one array")
+	skip := fs.Bool("skip", false, "with -join, report lines that are not valid JSON and go on")
+	options := syntaxFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	if *skip && !*join {
+		fs.Usage()
+		return errUsage
+	}
+	if *join {
+		return joinLines(e, fs.Args(), opts, *skip)
+	}
+
+	nw := sjson.NewNDJSONWriter(e.stdout)
+	return e.eachInput(fs.Args(), func(name string, r io.Reader) error {
+		dec := sjson.NewDecoderWithOptions(r, opts)
+		for tok, err := range dec.All() {
+			if err == nil {
+				err = writeLines(nw, tok, *split)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// writeLines writes tok as one line, or each of its elements if split
+// is set and tok is an array
+func writeLines(nw *sjson.NDJSONWriter, tok sjson.Token, split bool) error {
+	if !split || tok.Type() != sjson.ArrayType {
+		return nw.WriteToken(tok)
+	}
+
+	ct := tok.(sjson.ComplexToken)
+	for {
+		t, err := ct.Next()
+		if err != nil {
+			return err
+		}
+		switch t.Type() {
+		case sjson.EndType:
+			return nil
+		case sjson.CommentType:
+			continue
+		}
+		if err := nw.WriteToken(t); err != nil {
+			return err
+		}
+	}
+}
+
+// joinLines writes the values of the newline-delimited JSON in files
+// as the elements of one array
+func joinLines(e *env, files []string, decOpts sjson.DecoderOptions, skip bool) error {
+	enc := sjson.NewEncoder(e.stdout)
+	if err := enc.BeginArray(); err != nil {
+		return err
+	}
+
+	err := e.eachInput(files, func(name string, r io.Reader) error {
+		opts := []sjson.NDJSONOption{sjson.NDJSONDecoderOptions(decOpts)}
+		if skip {
+			opts = append(opts, sjson.SkipBadLines(func(err *sjson.LineError) {
+				fmt.Fprintf(e.stderr, "%s: %v\n", name, err)
+			}))
+		}
+
+		nr := sjson.NewNDJSONReader(r, opts...)
+		for {
+			tok, err := nr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err == nil {
+				err = enc.Copy(tok)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	})
+	if err != nil {
+		enc.Flush()
+		return err
+	}
+
+	return enc.End()
+}
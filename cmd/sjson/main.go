@@ -0,0 +1,198 @@
+// Command sjson formats, validates and queries JSON documents. Every
+// subcommand streams its input through an sjson.Decoder, so files larger
+// than memory can be processed.
+//
+// Usage:
+//
+//	sjson <command> [flags] [file...]
+//
+// The commands are:
+//
+//	fmt       pretty-print or compact JSON
+//	validate  check syntax, or conformance to a JSON Schema
+//	query     print the values selected by a JSON Pointer or JSONPath
+//	tokens    dump the token stream with positions and paths
+//	stats     count the values in the input
+//	lines     convert between JSON and newline-delimited JSON
+//
+// Files are read in turn; with none, or for "-", the standard input is
+// read. Run "sjson <command> -h" for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sheenobu/sjson"
+)
+
+// env is what a command runs in
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// A command is a subcommand of sjson
+type command struct {
+	name, summary string
+	run           func(e *env, args []string) error
+}
+
+var commands = []command{
+	{"fmt", "pretty-print or compact JSON", fmtCommand},
+	{"validate", "check syntax, or conformance to a JSON Schema", validateCommand},
+	{"query", "print the values selected by a JSON Pointer or JSONPath", queryCommand},
+	{"tokens", "dump the token stream with positions and paths", tokensCommand},
+	{"stats", "count the values in the input", statsCommand},
+	{"lines", "convert between JSON and newline-delimited JSON", linesCommand},
+}
+
+// errFailed makes sjson exit with status 1 without printing anything,
+// once the command has reported what went wrong
+var errFailed = errors.New("failed")
+
+// errUsage makes sjson exit with status 2, once the usage was printed
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run runs the command named by args[0] and returns the exit status
+func run(args []string, e *env) int {
+	if len(args) == 0 {
+		e.usage()
+		return 2
+	}
+
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+
+		err := c.run(e, args[1:])
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		case errors.Is(err, errFailed):
+			return 1
+		}
+		fmt.Fprintf(e.stderr, "sjson %s: %v\n", c.name, err)
+		return 1
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		e.usage()
+		return 0
+	}
+	fmt.Fprintf(e.stderr, "sjson: unknown command %q\n", args[0])
+	e.usage()
+	return 2
+}
+
+func (e *env) usage() {
+	fmt.Fprintf(e.stderr, "usage: sjson <command> [flags] [file...]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(e.stderr, "  %-9s %s\n", c.name, c.summary)
+	}
+}
+
+// flags creates the flag set of the command name, whose arguments are
+// described by args
+func (e *env) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: sjson %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of a command
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// eachInput calls fn with every file named in files in turn, or with
+// the standard input if there are none or the name is "-"
+func (e *env) eachInput(files []string, fn func(name string, r io.Reader) error) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, name := range files {
+		if err := e.input(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *env) input(name string, fn func(name string, r io.Reader) error) error {
+	if name == "-" {
+		return fn("<stdin>", e.stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(name, f)
+}
+
+// syntaxFlag registers the -syntax flag, which selects the dialect of
+// the input, and returns the decoder options for it
+func syntaxFlag(fs *flag.FlagSet) func() (sjson.DecoderOptions, error) {
+	syntax := fs.String("syntax", "json", "input `syntax`: json, jsonc or json5")
+	return func() (sjson.DecoderOptions, error) {
+		switch *syntax {
+		case "json":
+			return sjson.DecoderOptions{Syntax: sjson.JSON}, nil
+		case "jsonc":
+			return sjson.DecoderOptions{Syntax: sjson.JSONC}, nil
+		case "json5":
+			return sjson.DecoderOptions{Syntax: sjson.JSON5}, nil
+		}
+		return sjson.DecoderOptions{}, fmt.Errorf("unknown syntax %q", *syntax)
+	}
+}
+
+// walk reads tok to its end, calling fn with it and with every token
+// inside it
+func walk(tok sjson.Token, fn func(tok sjson.Token)) error {
+	fn(tok)
+
+	if mt, ok := tok.(*sjson.MemberToken); ok {
+		tok = mt.Value
+	}
+	ct, ok := tok.(sjson.ComplexToken)
+	if !ok {
+		return nil
+	}
+
+	for {
+		t, err := ct.Next()
+		if err != nil {
+			return err
+		}
+		if err := walk(t, fn); err != nil {
+			return err
+		}
+		if t.Type() == sjson.EndType {
+			return nil
+		}
+	}
+}
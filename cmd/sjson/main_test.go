@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// runSjson runs the command line args with stdin as the standard input
+// and returns the exit status and the output
+func runSjson(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+	return code, stdout.String(), stderr.String()
+}
+
+// tempFile writes data to a new file and returns its name
+func tempFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCommands(t *testing.T) {
+
+	Convey("fmt should indent or compact every value", t, func() {
+		code, out, _ := runSjson(`{"a": [1, 2.50], "b": {}} [true]`, "fmt")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "{\n  \"a\": [\n    1,\n    2.50\n  ],\n  \"b\": {}\n}\n[\n  true\n]\n")
+
+		code, out, _ = runSjson("{\"a\": [1, 2], // c\n}", "fmt", "-c", "-syntax", "jsonc")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, `{"a":[1,2]}`+"\n")
+
+		code, out, _ = runSjson(`{"b": [1, 2], "a": null}`, "fmt", "-sort", "100", "-width", "20")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "{\n  \"a\": null,\n  \"b\": [1, 2]\n}\n")
+
+		code, _, errOut := runSjson(`{"a": }`, "fmt")
+		So(code, ShouldEqual, 1)
+		So(errOut, ShouldStartWith, "sjson fmt: <stdin>: ")
+	})
+
+	Convey("validate should report every problem with its location", t, func() {
+		s := tempFile(t, "schema.json", `{"type": "object", "properties": {"n": {"type": "integer"}}, "required": ["n"]}`)
+
+		code, _, errOut := runSjson(`{"n": 1} {"n": "x"} {}`, "validate", "-schema", s)
+		So(code, ShouldEqual, 1)
+		So(errOut, ShouldEqual, "<stdin>: expected integer, found string at 1:16 (offset 15) in /n\n"+
+			"<stdin>: missing property \"n\" at 1:21 (offset 20)\n")
+
+		code, _, errOut = runSjson(`{"n": [1, }`, "validate")
+		So(code, ShouldEqual, 1)
+		So(errOut, ShouldStartWith, "<stdin>: ")
+
+		// syntax errors where the schema does not look fail as well
+		code, _, errOut = runSjson(`{"n": 1, "x": [1,, 2 zzz]}`, "validate", "-schema", s)
+		So(code, ShouldEqual, 1)
+		So(errOut, ShouldStartWith, "<stdin>: ")
+
+		good := tempFile(t, "good.json", `{"n": 2}`)
+		code, _, errOut = runSjson("", "validate", "-schema", s, good)
+		So(code, ShouldEqual, 0)
+		So(errOut, ShouldBeEmpty)
+	})
+
+	Convey("query should accept JSON Pointers and JSONPath", t, func() {
+		doc := `{"items": [{"id": 1, "tags": ["a"]}, {"id": 2}]}`
+
+		code, out, _ := runSjson(doc, "query", "/items/0")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, `{"id":1,"tags":["a"]}`+"\n")
+
+		code, out, _ = runSjson(doc+doc, "query", "-paths", "$..id")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "$['items'][0]['id']\t1\n$['items'][1]['id']\t2\n$['items'][0]['id']\t1\n$['items'][1]['id']\t2\n")
+
+		code, out, _ = runSjson(doc, "query", "/items/5")
+		So(code, ShouldEqual, 1)
+		So(out, ShouldBeEmpty)
+
+		code, _, errOut := runSjson(doc, "query", "$[")
+		So(code, ShouldEqual, 1)
+		So(errOut, ShouldContainSubstring, "invalid JSONPath")
+
+		code, _, _ = runSjson(doc, "query")
+		So(code, ShouldEqual, 2)
+	})
+
+	Convey("tokens should list positions, types and paths", t, func() {
+		code, out, _ := runSjson("{\"a\": [1, \"x\"], /* c */ \"b\": {}}", "tokens", "-syntax", "jsonc")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "0\t1:1\tobject\t\t\n"+
+			"1\t1:2\tmember\t/a\t\"a\" array\n"+
+			"7\t1:8\tnumber\t/a/0\t1\n"+
+			"10\t1:11\tstring\t/a/1\t\"x\"\n"+
+			"13\t1:14\tend\t/a\t\n"+
+			"16\t1:17\tcomment\t/a\t\"/* c */\"\n"+
+			"24\t1:25\tmember\t/b\t\"b\" object\n"+
+			"30\t1:31\tend\t/b\t\n"+
+			"31\t1:32\tend\t\t\n")
+	})
+
+	Convey("stats should count values", t, func() {
+		code, out, _ := runSjson(`{"a": [1, "x", null], "b": {"c": true}} 7`, "stats")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, `{
+  "values": 2,
+  "objects": 2,
+  "arrays": 1,
+  "members": 3,
+  "strings": 1,
+  "numbers": 2,
+  "bools": 1,
+  "nulls": 1,
+  "comments": 0,
+  "maxDepth": 2,
+  "bytes": 41
+}
+`)
+	})
+
+	Convey("lines should convert to and from newline-delimited JSON", t, func() {
+		code, out, _ := runSjson(`[{"a": 1}, [2]] "s"`, "lines")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "[{\"a\":1},[2]]\n\"s\"\n")
+
+		code, out, _ = runSjson(`[{"a": 1}, [2]] "s"`, "lines", "-split")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "{\"a\":1}\n[2]\n\"s\"\n")
+
+		code, out, _ = runSjson("{\"a\": 1}\n\n[2]\n", "lines", "-join")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "[{\"a\":1},[2]]\n")
+
+		code, out, errOut := runSjson("1\n{\n3\n", "lines", "-join", "-skip")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "[1,3]\n")
+		So(errOut, ShouldStartWith, "<stdin>: line 2: ")
+
+		code, out, _ = runSjson("{\"a\": 1} // one\n[2,]\n", "lines", "-join", "-syntax", "jsonc")
+		So(code, ShouldEqual, 0)
+		So(out, ShouldEqual, "[{\"a\":1},[2]]\n")
+
+		code, _, _ = runSjson("1\n", "lines", "-skip")
+		So(code, ShouldEqual, 2)
+	})
+
+	Convey("Unknown commands and missing files should fail", t, func() {
+		code, _, errOut := runSjson("", "frob")
+		So(code, ShouldEqual, 2)
+		So(errOut, ShouldContainSubstring, "unknown command")
+
+		code, _, _ = runSjson("")
+		So(code, ShouldEqual, 2)
+
+		code, _, errOut = runSjson("", "fmt", filepath.Join(t.TempDir(), "missing.json"))
+		So(code, ShouldEqual, 1)
+		So(errOut, ShouldContainSubstring, "missing.json")
+	})
+}
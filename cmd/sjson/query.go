@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sheenobu/sjson"
+)
+
+func queryCommand(e *env, args []string) error {
+	fs := e.flags("query", "[-paths] [-syntax syntax] expression [file...]")
+	paths := fs.Bool("paths", false, "print the path of each value before it, separated by a tab")
+	options := syntaxFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	expr := fs.Arg(0)
+
+	var query func(dec *sjson.Decoder, print func(path string, tok sjson.Token) error) error
+	if strings.HasPrefix(expr, "$") {
+		p, err := sjson.CompileJSONPath(expr)
+		if err != nil {
+			return err
+		}
+		query = func(dec *sjson.Decoder, print func(string, sjson.Token) error) error {
+			return queryPath(dec, p, print)
+		}
+	} else {
+		query = func(dec *sjson.Decoder, print func(string, sjson.Token) error) error {
+			return queryPointer(dec, expr, print)
+		}
+	}
+
+	enc := sjson.NewEncoder(e.stdout)
+	matched := false
+	print := func(path string, tok sjson.Token) error {
+		matched = true
+		if *paths {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(e.stdout, "%s\t", path); err != nil {
+				return err
+			}
+		}
+		return enc.Copy(tok)
+	}
+
+	err = e.eachInput(fs.Args()[1:], func(name string, r io.Reader) error {
+		if err := query(sjson.NewDecoderWithOptions(r, opts), print); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !matched {
+		return errFailed
+	}
+	return nil
+}
+
+// queryPath prints the values selected by p in every value of dec
+func queryPath(dec *sjson.Decoder, p *sjson.JSONPath, print func(path string, tok sjson.Token) error) error {
+	for {
+		start := dec.InputOffset()
+		for m, err := range p.Matches(dec) {
+			if err != nil {
+				return err
+			}
+			if err := print(m.Path, m.Value); err != nil {
+				return err
+			}
+		}
+
+		// Matches reads nothing at the end of the input
+		if dec.InputOffset() == start {
+			return nil
+		}
+	}
+}
+
+// queryPointer prints the value at pointer in the first value of dec.
+// A missing value is not an error.
+func queryPointer(dec *sjson.Decoder, pointer string, print func(path string, tok sjson.Token) error) error {
+	tok, err := dec.Seek(pointer)
+	if errors.Is(err, sjson.ErrNotFound) || err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return print(pointer, tok)
+}
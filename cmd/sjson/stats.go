@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sheenobu/sjson"
+)
+
+// stats counts what was read from the input
+type stats struct {
+	values   int64
+	objects  int64
+	arrays   int64
+	members  int64
+	strings  int64
+	numbers  int64
+	bools    int64
+	nulls    int64
+	comments int64
+	maxDepth int
+	bytes    int64
+
+	depth int
+}
+
+func statsCommand(e *env, args []string) error {
+	fs := e.flags("stats", "[-syntax syntax] [file...]")
+	options := syntaxFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+	opts.Comments = true
+
+	var st stats
+	err = e.eachInput(fs.Args(), func(name string, r io.Reader) error {
+		dec := sjson.NewDecoderWithOptions(r, opts)
+		defer func() {
+			st.bytes += dec.InputOffset()
+		}()
+
+		for {
+			tok, err := dec.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err == nil {
+				if tok.Type() != sjson.CommentType {
+					st.values++
+				}
+				err = walk(tok, st.count)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return st.write(sjson.NewEncoder(e.stdout))
+}
+
+func (st *stats) count(tok sjson.Token) {
+	if mt, ok := tok.(*sjson.MemberToken); ok {
+		st.members++
+		tok = mt.Value
+	}
+
+	switch tok.Type() {
+	case sjson.ObjectType, sjson.ArrayType:
+		if tok.Type() == sjson.ObjectType {
+			st.objects++
+		} else {
+			st.arrays++
+		}
+		st.depth++
+		st.maxDepth = max(st.maxDepth, st.depth)
+	case sjson.EndType:
+		st.depth--
+	case sjson.StringType:
+		st.strings++
+	case sjson.NumberType:
+		st.numbers++
+	case sjson.BoolType:
+		st.bools++
+	case sjson.NullType:
+		st.nulls++
+	case sjson.CommentType:
+		st.comments++
+	}
+}
+
+// write writes the counts as an indented JSON object
+func (st *stats) write(enc *sjson.Encoder) error {
+	enc.SetIndent("", "  ")
+	if err := enc.BeginObject(); err != nil {
+		return err
+	}
+
+	for _, f := range []struct {
+		key string
+		n   int64
+	}{
+		{"values", st.values},
+		{"objects", st.objects},
+		{"arrays", st.arrays},
+		{"members", st.members},
+		{"strings", st.strings},
+		{"numbers", st.numbers},
+		{"bools", st.bools},
+		{"nulls", st.nulls},
+		{"comments", st.comments},
+		{"maxDepth", int64(st.maxDepth)},
+		{"bytes", st.bytes},
+	} {
+		if err := enc.Key(f.key); err != nil {
+			return err
+		}
+		if err := enc.Int(f.n); err != nil {
+			return err
+		}
+	}
+	return enc.End()
+}
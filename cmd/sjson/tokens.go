@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sheenobu/sjson"
+)
+
+func tokensCommand(e *env, args []string) error {
+	fs := e.flags("tokens", "[-syntax syntax] [file...]")
+	options := syntaxFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+	opts.Comments = true
+
+	w := bufio.NewWriter(e.stdout)
+	defer w.Flush()
+
+	return e.eachInput(fs.Args(), func(name string, r io.Reader) error {
+		dec := sjson.NewDecoderWithOptions(r, opts)
+		print := func(tok sjson.Token) {
+			pos := tok.Position()
+			fmt.Fprintf(w, "%d\t%d:%d\t%s\t%s\t%s\n", pos.Offset, pos.Line, pos.Column,
+				typeName(tok.Type()), tok.Pointer(), tokenText(tok))
+		}
+
+		for {
+			tok, err := dec.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err == nil {
+				err = walk(tok, print)
+			}
+			if err != nil {
+				w.Flush()
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	})
+}
+
+// typeName returns the name of typ without its Type suffix, such as
+// "object"
+func typeName(typ sjson.Type) string {
+	return strings.ToLower(strings.TrimSuffix(typ.String(), "Type"))
+}
+
+// tokenText returns the text of tok: the quoted key and the value of a
+// member, with the type of the value in place of objects and arrays,
+// or the value of a simple token
+func tokenText(tok sjson.Token) string {
+	switch tok.Type() {
+	case sjson.ObjectType, sjson.ArrayType, sjson.EndType:
+		return ""
+	case sjson.MemberType:
+		mt := tok.(*sjson.MemberToken)
+		if typ := mt.Value.Type(); typ == sjson.ObjectType || typ == sjson.ArrayType {
+			return strconv.Quote(mt.Key) + " " + typeName(typ)
+		}
+		return strconv.Quote(mt.Key) + " " + tokenText(mt.Value)
+	case sjson.StringType, sjson.CommentType:
+		return strconv.Quote(fmt.Sprint(tok))
+	}
+	return fmt.Sprint(tok)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sheenobu/sjson"
+	"github.com/sheenobu/sjson/schema"
+)
+
+func validateCommand(e *env, args []string) error {
+	fs := e.flags("validate", "[-schema file] [-syntax syntax] [file...]")
+	schemaFile := fs.String("schema", "", "check every value against the JSON Schema in `file`")
+	options := syntaxFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	var s *schema.Schema
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err != nil {
+			return err
+		}
+		if s, err = schema.Compile(data); err != nil {
+			return fmt.Errorf("%s: %w", *schemaFile, err)
+		}
+	}
+
+	failed := false
+	err = e.eachInput(fs.Args(), func(name string, r io.Reader) error {
+		report := func(err error) {
+			fmt.Fprintf(e.stderr, "%s: %v\n", name, err)
+			failed = true
+		}
+		validate(sjson.NewDecoderWithOptions(r, opts), s, report)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// validate reads every value from dec, checking it against s if it is
+// set, and reports each violation. Reading stops at the first error in
+// the input, which is reported too. Every token is decoded, with or
+// without a schema, so malformed input always fails.
+func validate(dec *sjson.Decoder, s *schema.Schema, report func(err error)) {
+	for {
+		var err error
+		if s != nil {
+			err = s.Validate(dec)
+		} else {
+			err = readValue(dec)
+		}
+
+		var ve *schema.ValidationError
+		switch {
+		case err == io.EOF:
+			return
+		case errors.As(err, &ve):
+			for _, v := range ve.Violations {
+				report(v)
+			}
+		case err != nil:
+			report(err)
+			return
+		}
+	}
+}
+
+// readValue reads the next value from dec to its end, decoding every
+// token so that all of it is checked
+func readValue(dec *sjson.Decoder) error {
+	for {
+		tok, err := dec.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Type() != sjson.CommentType {
+			return walk(tok, func(sjson.Token) {})
+		}
+	}
+}
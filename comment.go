@@ -0,0 +1,61 @@
+package sjson
+
+import (
+	"io"
+)
+
+type commentToken struct {
+	*scalar
+}
+
+func (c commentToken) Type() Type {
+	return CommentType
+}
+
+// String returns the comment including its // or /* */ delimiters
+func (c commentToken) String() string {
+	return string(c.text)
+}
+
+// scanComment consumes a // line comment up to the end of the line, or
+// a /* */ block comment, and returns its text
+func (r *reader) scanComment() ([]byte, error) {
+	d, err := r.Peek(2)
+	if err == io.EOF {
+		return nil, r.eofError("Unexpected end of input in comment")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d[0] != '/' || (d[1] != '/' && d[1] != '*') {
+		return nil, r.unexpected(d[:1], "comment")
+	}
+
+	block := d[1] == '*'
+	text := []byte{d[0], d[1]}
+	r.Discard(2)
+
+	for {
+		d, err := r.Peek(1)
+		if err == io.EOF && !block {
+			return text, nil
+		}
+		if err == io.EOF {
+			return nil, r.eofError("Unexpected end of input in comment")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		b := d[0]
+		if !block && (b == '\n' || b == '\r') {
+			return text, nil
+		}
+		r.Discard(1)
+
+		text = append(text, b)
+		if block && b == '/' && len(text) >= 4 && text[len(text)-2] == '*' {
+			return text, nil
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package sjson
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decode reads the next value from the input and stores it in v, which
+// must be a non-nil pointer. Objects and arrays are decoded as they
+// are read, without buffering the whole value.
+func (dec *Decoder) Decode(v interface{}) error {
+	tok, err := nextValue(dec)
+	if err != nil {
+		return err
+	}
+
+	return DecodeToken(tok, v)
+}
+
+// DecodeToken stores the value of tok in v, which must be a non-nil
+// pointer. Objects and arrays are read to their end; a MemberToken
+// decodes its value.
+//
+// Objects decode into structs, maps with string, integer or
+// encoding.TextUnmarshaler keys and interface{} values; arrays decode
+// into slices, arrays and interface{} values. Struct fields honor
+// `json:"name,omitempty,string"` tags. Values that implement
+// TokenUnmarshaler, json.Unmarshaler or encoding.TextUnmarshaler
+// decode themselves, in that order of preference.
+//
+// If a value cannot be stored in its Go type, decoding continues past
+// it and the first *UnmarshalTypeError is returned at the end.
+func DecodeToken(tok Token, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return metaOf(tok).typeError(describe(tok), reflect.TypeOf(v))
+	}
+
+	d := &valueDecoder{}
+	if err := d.value(tok, rv.Elem()); err != nil {
+		return err
+	}
+
+	return d.typeErr
+}
+
+// valueDecoder decodes tokens into Go values, remembering the first
+// type mismatch so decoding can continue past it
+type valueDecoder struct {
+	typeErr error
+}
+
+// mismatch records a type mismatch and discards the rest of tok
+func (d *valueDecoder) mismatch(tok Token, t reflect.Type) error {
+	if d.typeErr == nil {
+		d.typeErr = metaOf(tok).typeError(describe(tok), t)
+	}
+
+	return discard(tok)
+}
+
+func (d *valueDecoder) value(tok Token, v reflect.Value) error {
+	if mt, ok := tok.(*MemberToken); ok {
+		tok = mt.Value
+	}
+
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		if ok, err := unmarshaler(tok, v); ok {
+			if _, ok := err.(*UnmarshalTypeError); ok {
+				return d.mismatch(tok, v.Type())
+			}
+			return err
+		}
+	}
+
+	if tok.Type() == NullType {
+		switch v.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.value(tok, v.Elem())
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		i, err := d.generic(tok)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(i))
+		return nil
+	}
+
+	switch tok.Type() {
+	case StringType, BoolType:
+		err := tok.(SimpleToken).Unmarshal(v.Addr().Interface())
+		if _, ok := err.(*UnmarshalTypeError); ok {
+			return d.mismatch(tok, v.Type())
+		}
+		return err
+	case NumberType:
+		if err := metaOf(tok).setNumber(v, toText(tok)); err != nil {
+			return d.mismatch(tok, v.Type())
+		}
+		return nil
+	case ObjectType:
+		switch v.Kind() {
+		case reflect.Struct:
+			return d.object(tok.(ComplexToken), v)
+		case reflect.Map:
+			return d.mapping(tok.(ComplexToken), v)
+		}
+	case ArrayType:
+		switch v.Kind() {
+		case reflect.Slice:
+			return d.slice(tok.(ComplexToken), v)
+		case reflect.Array:
+			return d.array(tok.(ComplexToken), v)
+		}
+	}
+
+	return d.mismatch(tok, v.Type())
+}
+
+// object decodes the members of ct into the fields of struct v
+func (d *valueDecoder) object(ct ComplexToken, v reflect.Value) error {
+	fields := cachedFields(v.Type())
+
+	for {
+		tok, err := nextValue(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == EndType {
+			return nil
+		}
+
+		mt := tok.(*MemberToken)
+		f := lookupField(fields, mt.Key)
+		if f == nil {
+			if err := discard(mt.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			// like encoding/json, an embedded pointer to an unexported
+			// struct cannot be allocated
+			if err := d.mismatch(mt.Value, fv.Type()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.quoted && mt.Value.Type() == StringType {
+			err = d.quoted(mt.Value, fv)
+		} else {
+			err = d.value(mt.Value, fv)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// quoted decodes a value given as a JSON string, as requested by the
+// `json:",string"` tag option. The string must hold nothing but the
+// value.
+func (d *valueDecoder) quoted(tok Token, v reflect.Value) error {
+	dec := NewDecoderWithOptions(strings.NewReader(toText(tok)), DecoderOptions{Mode: Strict})
+
+	inner, err := dec.Next()
+	if err != nil {
+		return d.mismatch(tok, v.Type())
+	}
+
+	switch inner.Type() {
+	case StringType, NumberType, BoolType, NullType:
+	default:
+		if err := discard(inner); err != nil {
+			return err
+		}
+		return d.mismatch(tok, v.Type())
+	}
+
+	inner = withMeta(inner, metaOf(tok))
+	return d.value(inner, v)
+}
+
+// mapping decodes the members of ct into the map v
+func (d *valueDecoder) mapping(ct ComplexToken, v reflect.Value) error {
+	t := v.Type()
+	textKey := reflect.PointerTo(t.Key()).Implements(textUnmarshalerType)
+
+	switch t.Key().Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !textKey {
+			return d.mismatch(ct.(Token), t)
+		}
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+
+	for {
+		tok, err := nextValue(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == EndType {
+			return nil
+		}
+
+		mt := tok.(*MemberToken)
+
+		kv := reflect.New(t.Key()).Elem()
+		if textKey {
+			err = kv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(mt.Key))
+		} else if kv.Kind() == reflect.String {
+			kv.SetString(mt.Key)
+		} else {
+			err = metaOf(mt).setNumber(kv, mt.Key)
+		}
+		if err != nil {
+			if d.typeErr == nil {
+				d.typeErr = metaOf(mt).typeError("object key "+strconv.Quote(mt.Key), t.Key())
+			}
+			if err := discard(mt.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ev := reflect.New(t.Elem()).Elem()
+		if err := d.value(mt.Value, ev); err != nil {
+			return err
+		}
+		v.SetMapIndex(kv, ev)
+	}
+}
+
+// slice decodes the elements of ct into the slice v
+func (d *valueDecoder) slice(ct ComplexToken, v reflect.Value) error {
+	v.SetLen(0)
+
+	for i := 0; ; i++ {
+		tok, err := nextValue(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == EndType {
+			if v.IsNil() {
+				v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+			}
+			return nil
+		}
+
+		if i >= v.Cap() {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		} else {
+			v.SetLen(i + 1)
+			v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+		}
+
+		if err := d.value(tok, v.Index(i)); err != nil {
+			return err
+		}
+	}
+}
+
+// array decodes the elements of ct into the array v. Extra elements
+// are discarded and missing ones are zeroed.
+func (d *valueDecoder) array(ct ComplexToken, v reflect.Value) error {
+	for i := 0; ; i++ {
+		tok, err := nextValue(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == EndType {
+			for ; i < v.Len(); i++ {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+			}
+			return nil
+		}
+
+		if i >= v.Len() {
+			err = discard(tok)
+		} else {
+			err = d.value(tok, v.Index(i))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// generic decodes tok into the interface{} representation used by
+// encoding/json: map[string]interface{}, []interface{}, float64,
+// string, bool or nil
+func (d *valueDecoder) generic(tok Token) (interface{}, error) {
+	switch tok.Type() {
+	case StringType:
+		return toText(tok), nil
+	case NumberType:
+		var f float64
+		if err := metaOf(tok).setNumber(reflect.ValueOf(&f).Elem(), toText(tok)); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case BoolType:
+		return toText(tok) == "true", nil
+	case NullType:
+		return nil, nil
+	case ObjectType:
+		m := make(map[string]interface{})
+		return m, d.mapping(tok.(ComplexToken), reflect.ValueOf(&m).Elem())
+	case ArrayType:
+		s := make([]interface{}, 0)
+		return s, d.slice(tok.(ComplexToken), reflect.ValueOf(&s).Elem())
+	}
+
+	return nil, metaOf(tok).typeError(describe(tok), reflect.TypeOf((*interface{})(nil)).Elem())
+}
+
+// fieldByIndex returns the nested field of v, allocating nil embedded
+// struct pointers on the way. If one of them cannot be set, it returns
+// that pointer and false.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return v, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// nextValue returns the next token of ct that is not a comment
+func nextValue(ct ComplexToken) (Token, error) {
+	for {
+		tok, err := ct.Next()
+		if err != nil || tok.Type() != CommentType {
+			return tok, err
+		}
+	}
+}
+
+// discard skips the rest of tok if it is an object or array
+func discard(tok Token) error {
+	if mt, ok := tok.(*MemberToken); ok {
+		tok = mt.Value
+	}
+
+	if ct, ok := tok.(ComplexToken); ok {
+		return ct.Skip()
+	}
+	return nil
+}
+
+// describe names the kind of JSON value tok holds, for errors
+func describe(tok Token) string {
+	switch tok.Type() {
+	case NumberType:
+		return "number " + toText(tok)
+	case StringType:
+		return "string"
+	case BoolType:
+		return "bool"
+	case NullType:
+		return "null"
+	case ObjectType:
+		return "object"
+	case ArrayType:
+		return "array"
+	case MemberType:
+		return "member"
+	case CommentType:
+		return "comment"
+	}
+	return "end of object or array"
+}
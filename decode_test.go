@@ -0,0 +1,319 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type decodeInner struct {
+	Name string `json:"name"`
+}
+
+type DecodeEmbedded struct {
+	Promoted string `json:"promoted"`
+}
+
+type decodeOuter struct {
+	DecodeEmbedded
+
+	ID      int64             `json:"id,string"`
+	Title   string            `json:"title,omitempty"`
+	Score   float64           `json:"score"`
+	Tags    []string          `json:"tags"`
+	Pair    [2]int            `json:"pair"`
+	Inner   *decodeInner      `json:"inner"`
+	Counts  map[string]int    `json:"counts"`
+	ByID    map[int]string    `json:"by_id"`
+	Any     interface{}       `json:"any"`
+	Skipped string            `json:"-"`
+	Nested  []decodeInner     `json:"nested"`
+	Extra   map[string]string `json:"extra"`
+	Enabled bool
+}
+
+type decodeHidden struct {
+	X int
+}
+
+type decodeHiddenPtr struct {
+	*decodeHidden
+	Y int
+}
+
+type DecodeLowerID struct {
+	ID int `json:"id"`
+}
+
+type decodeUpperID struct {
+	DecodeLowerID
+	ID int
+}
+
+func TestDecode(t *testing.T) {
+
+	Convey("An object should decode into a struct", t, func() {
+		r := strings.NewReader(`{
+			"id": "42",
+			"title": "hello",
+			"score": 9.5,
+			"tags": ["a", "b"],
+			"pair": [1, 2, 3],
+			"inner": {"name": "in"},
+			"counts": {"x": 1, "y": 2},
+			"by_id": {"7": "seven"},
+			"any": {"list": [1, "two", true, null]},
+			"-": "no",
+			"Skipped": "no",
+			"unknown": {"deep": [1, {"x": 2}]},
+			"nested": [{"name": "n1"}, {"name": "n2"}],
+			"extra": null,
+			"promoted": "up",
+			"enabled": true
+		}`)
+		dec := NewDecoder(r)
+
+		var v decodeOuter
+		err := dec.Decode(&v)
+		So(err, ShouldBeNil)
+
+		So(v.ID, ShouldEqual, 42)
+		So(v.Title, ShouldEqual, "hello")
+		So(v.Score, ShouldEqual, 9.5)
+		So(v.Tags, ShouldResemble, []string{"a", "b"})
+		So(v.Pair, ShouldResemble, [2]int{1, 2})
+		So(v.Inner, ShouldResemble, &decodeInner{Name: "in"})
+		So(v.Counts, ShouldResemble, map[string]int{"x": 1, "y": 2})
+		So(v.ByID, ShouldResemble, map[int]string{7: "seven"})
+		So(v.Any, ShouldResemble, map[string]interface{}{
+			"list": []interface{}{1.0, "two", true, nil},
+		})
+		So(v.Skipped, ShouldEqual, "")
+		So(v.Nested, ShouldResemble, []decodeInner{{Name: "n1"}, {Name: "n2"}})
+		So(v.Extra, ShouldBeNil)
+		So(v.Promoted, ShouldEqual, "up")
+		So(v.Enabled, ShouldBeTrue)
+
+		_, err = dec.Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Decode should stream consecutive values", t, func() {
+		dec := NewDecoder(strings.NewReader(`[1, 2] {"a": "b"} "c"`))
+
+		var s []int
+		So(dec.Decode(&s), ShouldBeNil)
+		So(s, ShouldResemble, []int{1, 2})
+
+		var m map[string]string
+		So(dec.Decode(&m), ShouldBeNil)
+		So(m, ShouldResemble, map[string]string{"a": "b"})
+
+		var i interface{}
+		So(dec.Decode(&i), ShouldBeNil)
+		So(i, ShouldEqual, "c")
+	})
+
+	Convey("DecodeToken should decode a member of an object", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"skip": [1, 2], "want": {"name": "x"}}`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(DecodeToken(t, new(interface{})), ShouldBeNil)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+
+		var in decodeInner
+		So(DecodeToken(t, &in), ShouldBeNil)
+		So(in.Name, ShouldEqual, "x")
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, EndType)
+	})
+
+	Convey("Type mismatches should be reported after decoding the rest", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"name": [1, {"a": 2}], "id": "x", "score": 1} 7`))
+
+		var v decodeOuter
+		v.Inner = &decodeInner{}
+		err := dec.Decode(&v)
+
+		var ute *UnmarshalTypeError
+		So(errors.As(err, &ute), ShouldBeTrue)
+		So(ute.Path, ShouldEqual, "/id")
+		So(v.Score, ShouldEqual, 1)
+
+		var i int
+		So(dec.Decode(&i), ShouldBeNil)
+		So(i, ShouldEqual, 7)
+	})
+
+	Convey("Decode should reject non-pointers", t, func() {
+		dec := NewDecoder(strings.NewReader(`1`))
+
+		var i int
+		err := dec.Decode(i)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+	})
+}
+
+// upperID decodes a string as upper case text
+type upperID string
+
+func (id *upperID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty id")
+	}
+	*id = upperID(strings.ToUpper(string(text)))
+	return nil
+}
+
+// rawJSONValue keeps the JSON text it was decoded from
+type rawJSONValue struct {
+	raw string
+}
+
+func (r *rawJSONValue) UnmarshalJSON(b []byte) error {
+	r.raw = string(b)
+	return nil
+}
+
+// keyCounter counts the members of an object as they are read, and only
+// decodes the first one
+type keyCounter struct {
+	keys  int
+	first int
+}
+
+func (k *keyCounter) UnmarshalToken(tok Token) error {
+	ot, ok := tok.(ObjectToken)
+	if !ok {
+		return errors.New("not an object")
+	}
+
+	for _, v := range ot.Members() {
+		if k.keys == 0 {
+			if err := DecodeToken(v, &k.first); err != nil {
+				return err
+			}
+		}
+		k.keys++
+	}
+	return ot.Err()
+}
+
+func TestUnmarshalers(t *testing.T) {
+
+	Convey("Values should decode themselves through unmarshaler interfaces", t, func() {
+		var v struct {
+			When   time.Time              `json:"when"`
+			ID     upperID                `json:"id"`
+			IDPtr  *upperID               `json:"idPtr"`
+			Raw    rawJSONValue           `json:"raw"`
+			RawStr rawJSONValue           `json:"rawStr"`
+			Count  keyCounter             `json:"count"`
+			Keys   map[upperID]int        `json:"keys"`
+			Nested map[string]*keyCounter `json:"nested"`
+			After  int                    `json:"after"`
+		}
+		doc := `{"when": "2024-05-01T10:00:00Z", "id": "ab", "idPtr": "cd", "raw": {"a": [1, 2.50]}, "rawStr": "q\"",
+			"count": {"x": 7, "y": {"z": []}, "w": 1}, "keys": {"k": 1}, "nested": {"n": {"only": 3}}, "after": 9}`
+
+		So(NewDecoder(strings.NewReader(doc)).Decode(&v), ShouldBeNil)
+		So(v.When.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)), ShouldBeTrue)
+		So(v.ID, ShouldEqual, upperID("AB"))
+		So(*v.IDPtr, ShouldEqual, upperID("CD"))
+		So(v.Raw.raw, ShouldEqual, `{"a": [1, 2.50]}`)
+		So(v.RawStr.raw, ShouldEqual, `"q\""`)
+		So(v.Count, ShouldResemble, keyCounter{keys: 3, first: 7})
+		So(v.Keys, ShouldResemble, map[upperID]int{"K": 1})
+		So(v.Nested["n"], ShouldResemble, &keyCounter{keys: 1, first: 3})
+		So(v.After, ShouldEqual, 9)
+	})
+
+	Convey("Simple tokens should honor unmarshalers too", t, func() {
+		dec := NewDecoder(strings.NewReader(`["2024-05-01", null, 5]`))
+		tok, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := tok.(ComplexToken)
+
+		var id upperID
+		tok, _ = ct.Next()
+		So(tok.(SimpleToken).Unmarshal(&id), ShouldBeNil)
+		So(id, ShouldEqual, upperID("2024-05-01"))
+
+		var raw rawJSONValue
+		tok, _ = ct.Next()
+		So(tok.(SimpleToken).Unmarshal(&raw), ShouldBeNil)
+		So(raw.raw, ShouldEqual, "null")
+
+		tok, _ = ct.Next()
+		err = tok.(SimpleToken).Unmarshal(&id)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+	})
+
+	Convey("Unmarshaler errors and mismatches should be reported", t, func() {
+		var v struct {
+			ID   upperID `json:"id"`
+			When time.Time
+			N    int
+		}
+
+		err := NewDecoder(strings.NewReader(`{"id": {"a": 1}, "N": 2}`)).Decode(&v)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+		So(err.(*UnmarshalTypeError).Path, ShouldEqual, "/id")
+		So(v.N, ShouldEqual, 2)
+
+		err = NewDecoder(strings.NewReader(`{"when": 5}`)).Decode(&v)
+		So(err, ShouldNotBeNil)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeFalse)
+
+		err = NewDecoder(strings.NewReader(`{"id": ""}`)).Decode(&v)
+		So(err, ShouldBeError, "empty id")
+
+		var k keyCounter
+		err = NewDecoder(strings.NewReader(`[1]`)).Decode(&k)
+		So(err, ShouldBeError, "not an object")
+	})
+
+	Convey("An embedded pointer to an unexported struct should be a type mismatch", t, func() {
+		var v decodeHiddenPtr
+		err := NewDecoder(strings.NewReader(`{"X": 1, "Y": 2}`)).Decode(&v)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+		So(err.(*UnmarshalTypeError).Path, ShouldEqual, "/X")
+		So(v.decodeHidden, ShouldBeNil)
+		So(v.Y, ShouldEqual, 2)
+
+		v = decodeHiddenPtr{decodeHidden: &decodeHidden{}}
+		So(NewDecoder(strings.NewReader(`{"X": 1}`)).Decode(&v), ShouldBeNil)
+		So(v.X, ShouldEqual, 1)
+	})
+
+	Convey("A quoted field should hold nothing but its value", t, func() {
+		var v struct {
+			A int `json:",string"`
+		}
+		err := NewDecoder(strings.NewReader(`{"A": "12 34"}`)).Decode(&v)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+
+		So(NewDecoder(strings.NewReader(`{"A": "12"}`)).Decode(&v), ShouldBeNil)
+		So(v.A, ShouldEqual, 12)
+	})
+
+	Convey("Promoted fields should only be hidden by fields of the exact same name", t, func() {
+		var v decodeUpperID
+		So(NewDecoder(strings.NewReader(`{"ID": 1, "id": 2}`)).Decode(&v), ShouldBeNil)
+		So(v.ID, ShouldEqual, 1)
+		So(v.DecodeLowerID.ID, ShouldEqual, 2)
+	})
+}
@@ -1,318 +1,753 @@
 package sjson
 
 import (
-	"bufio"
 	"bytes"
 	"io"
-
-	"strings"
-
-	"github.com/pkg/errors"
+	"unicode/utf8"
 )
 
-type stateFn func() (stateFn, bool, error)
+// A stateFn is a decoder state. States are method expressions, so
+// switching between them does not allocate.
+type stateFn func(*frame) (stateFn, bool, error)
 
 // A Decoder is a JSON decoder
 type Decoder struct {
-	r *bufio.Reader
+	frame
+
+	// values counts the top-level values read
+	values int
+}
 
-	currentState stateFn
-	initialState stateFn
+// A frame decodes the top-level values, or the members or elements of
+// an object or array. The frames of the open objects and arrays are kept
+// by the reader, one for each level of nesting. With ReuseTokens, each
+// object or array at a level reuses the frame of that level.
+type frame struct {
+	// the position and path of the object or array
+	tokenInfo
+
+	r *reader
+
+	state stateFn
+	tok   Token
+
+	// parent is the path of the containing object or array, nil at the
+	// top level, and key the key of the member being decoded, if keyed
+	parent *pathNode
+	key    string
+	keyed  bool
+
+	// array frames count their elements, and object frames their
+	// members
+	array bool
+	count int
+	depth int
+
+	// started is set once the first child token has been requested,
+	// and done once the closing brace or bracket has been consumed
+	started bool
+	done    bool
+
+	// pending holds the comments found inside a member
+	pending []Token
+
+	// want, when set, selects the members or elements to decode; the
+	// others are skipped without being decoded
+	want func(n *pathNode) bool
+
+	// err is the first error encountered by Members or Elements
+	err error
+
+	// member and value hold the last member and the last string,
+	// number, literal name or end token, and are reused for the next
+	// with ReuseTokens
+	member MemberToken
+	value  scalar
+}
 
-	tok Token
+// frameAt returns a frame for an object or array at depth, which is
+// the frame of that level with ReuseTokens and a new one otherwise
+func (r *reader) frameAt(depth int) *frame {
+	for len(r.frames) <= depth {
+		r.frames = append(r.frames, nil)
+	}
+	if r.frames[depth] == nil || !r.opts.ReuseTokens {
+		r.frames[depth] = new(frame)
+	}
+	return r.frames[depth]
+}
+
+// skipChildren consumes the rest of the objects and arrays left open
+// inside the frame, which are then done
+func (f *frame) skipChildren() error {
+	for depth := f.r.level; depth > f.depth; depth-- {
+		if depth < len(f.r.frames) && f.r.frames[depth] != nil {
+			f.r.frames[depth].done = true
+		}
+	}
+	return f.r.skipTo(f.depth)
+}
+
+// newScalar returns the scalar of a token beginning at info. With
+// ReuseTokens it is the scalar of the frame, holding text as is;
+// otherwise it is a new one holding a copy of text.
+func (f *frame) newScalar(info tokenInfo, text []byte) *scalar {
+	if f.r.opts.ReuseTokens {
+		f.value = scalar{tokenInfo: info, text: text}
+		return &f.value
+	}
+	return &scalar{tokenInfo: info, text: bytes.Clone(text)}
 }
 
 // NewDecoder creates a new decoder from the given reader
 func NewDecoder(r io.Reader) *Decoder {
-	d := &Decoder{
-		r: bufio.NewReader(r),
-	}
-	d.initialState = d.beginState
+	return &Decoder{frame: frame{
+		r:     newReader(r),
+		state: (*frame).beginState,
+	}}
+}
+
+// InputOffset returns the byte offset of the current position in the input
+func (dec *Decoder) InputOffset() int64 {
+	return dec.r.offset()
+}
 
-	return d
+// Position returns the current position in the input
+func (dec *Decoder) Position() Position {
+	return dec.r.position()
 }
 
-// newDecoder creates a new decoder from the given reader
-func newDecoder(r *bufio.Reader) *Decoder {
-	d := &Decoder{
-		r: r,
+// Path returns the path of the last token read from the input, by the
+// decoder or by any object or array read through it
+func (dec *Decoder) Path() []PathElement {
+	if dec.r.current == nil {
+		return nil
 	}
-	d.initialState = d.beginState
+	n := metaOf(dec.r.current).elem
+	return n.elements()
+}
 
-	return d
+// Pointer returns the path of the last token read from the input as a
+// JSON Pointer
+func (dec *Decoder) Pointer() string {
+	if dec.r.current == nil {
+		return ""
+	}
+	n := metaOf(dec.r.current).elem
+	return n.pointer()
 }
 
 // Next gets the next token
 func (dec *Decoder) Next() (Token, error) {
+	if dec.r.opts.Mode == Sequence {
+		return dec.nextRecord()
+	}
+	return dec.next()
+}
+
+// next reads the next top-level token
+func (dec *Decoder) next() (Token, error) {
+	if len(dec.pending) == 0 && dec.values > 0 && dec.r.opts.Mode == Strict {
+		return nil, dec.end()
+	}
+	tok, err := dec.frame.next()
+	if err == nil && tok.Type() != CommentType {
+		dec.values++
+	}
+	return tok, err
+}
+
+// next reads the next token of the frame
+func (f *frame) next() (Token, error) {
+	if len(f.pending) > 0 {
+		tok := f.pending[0]
+		f.pending = f.pending[1:]
+		return tok, nil
+	}
+
+	if f.r.level > f.depth {
+		// the rest of a partly read object or array is not returned
+		if err := f.skipChildren(); err != nil {
+			return nil, f.annotate(err)
+		}
+	}
+
 	var ok bool
 	var err error
 
-	dec.currentState = dec.initialState
-
-	for {
-		dec.currentState, ok, err = dec.currentState()
+	// plain JSON has no comments to look for between the states
+	relaxed := f.r.opts.Syntax != JSON
+
+	state := f.state
+	for !ok {
+		if !relaxed {
+			err = f.r.skipSpace()
+		} else {
+			var comment Token
+			if comment, err = f.space(); comment != nil {
+				// resume where the comment was found
+				f.state = state
+				f.tok, ok = comment, true
+				break
+			}
+		}
+		if err == io.EOF {
+			// running out of input is left to the states
+			err = nil
+		}
+		if err == nil {
+			state, ok, err = state(f)
+		}
 		if err != nil {
-			return nil, err
+			return nil, f.annotate(err)
 		}
-		if ok {
-			return dec.tok, nil
+		if state == nil {
+			state = f.state
 		}
-		if dec.currentState == nil {
-			dec.currentState = dec.initialState
+	}
+
+	if err := f.countToken(); err != nil {
+		return nil, f.annotate(err)
+	}
+	if f.tok.Type() == CommentType {
+		return f.tok, nil
+	}
+	f.r.current = f.tok
+
+	if f.r.level == 0 && f.r.opts.Mode != Concatenated {
+		// reading ahead leaves the text of the token where it is
+		f.r.pinned = true
+		err := f.endValue()
+		f.r.pinned = false
+		if err != nil {
+			// what follows the value is at the top level
+			return nil, f.annotateAt(err, "")
 		}
 	}
+	if f.array && f.tok.Type() != EndType {
+		f.count++
+		f.state = (*frame).arrayState
+	}
+	return f.tok, nil
 }
 
-func (dec *Decoder) beginState() (stateFn, bool, error) {
-	d, err := dec.r.Peek(1)
+func (f *frame) beginState() (stateFn, bool, error) {
+	d, err := f.peek()
 	if err != nil {
 		return nil, false, err
 	}
 
+	json5 := f.r.opts.Syntax == JSON5
+
 	switch {
-	case d[0] == '"':
-		return dec.stringState, false, nil
-	case d[0] >= '0' && d[0] <= '9':
-		return dec.numberState, false, nil
+	case d[0] == '"' || (json5 && d[0] == '\''):
+		return (*frame).stringState, false, nil
+	case d[0] == '-' || (d[0] >= '0' && d[0] <= '9'):
+		return (*frame).numberState, false, nil
+	case json5 && (d[0] == '+' || d[0] == '.' || d[0] == 'I' || d[0] == 'N'):
+		return (*frame).numberState, false, nil
 	case d[0] == 't' || d[0] == 'f':
-		return dec.boolState, false, nil
+		return (*frame).boolState, false, nil
 	case d[0] == 'n':
-		return dec.nullState, false, nil
-	case d[0] == '{':
-		dec.r.Discard(1)
-		dec.tok = &objectToken{r: dec.r}
-		return nil, true, nil
-	case d[0] == ' ' || d[0] == '\t' || d[0] == '\n' || d[0] == '\r':
-		dec.r.Discard(1)
-		return dec.beginState, false, nil
-	case d[0] == '[':
-		dec.r.Discard(1)
-		dec.tok = &arrayToken{r: dec.r}
-		return nil, true, nil
-	case d[0] == '}':
-		return nil, true, nil
+		return (*frame).nullState, false, nil
+	case d[0] == '{' || d[0] == '[':
+		return (*frame).containerState, false, nil
 	default:
-		return nil, false, errors.Errorf("Unexpected token %s", d)
+		return nil, false, f.r.unexpected(d, "value")
+	}
+}
+
+func (f *frame) arrayBeginState() (stateFn, bool, error) {
+	d, err := f.peek()
+	if err != nil {
+		return nil, false, err
 	}
+
+	if d[0] == ']' {
+		return f.closeContainer()
+	}
+	return f.elementState()
 }
 
-func (dec *Decoder) arrayState() (stateFn, bool, error) {
-	d, err := dec.r.Peek(1)
+func (f *frame) arrayState() (stateFn, bool, error) {
+	d, err := f.peek()
 	if err != nil {
 		return nil, false, err
 	}
 
 	switch {
-	case d[0] == '"':
-		return dec.stringState, false, nil
-	case d[0] >= '0' && d[0] <= '9':
-		return dec.numberState, false, nil
-	case d[0] == 't' || d[0] == 'f':
-		return dec.boolState, false, nil
-	case d[0] == 'n':
-		return dec.nullState, false, nil
-	case d[0] == '{':
-		dec.initialState = dec.arrayState
-		dec.r.Discard(1)
-		dec.tok = &objectToken{r: dec.r}
-		return nil, true, nil
-	case d[0] == ' ' || d[0] == '\t' || d[0] == '\n' || d[0] == '\r':
-		dec.r.Discard(1)
-		return dec.arrayState, false, nil
-	case d[0] == '[':
-		dec.r.Discard(1)
-		dec.tok = &arrayToken{r: dec.r}
-		return nil, true, nil
-	case d[0] == ']':
-		dec.r.Discard(1)
-		dec.tok = endToken
-		return nil, true, nil
-	case d[0] == '}':
-		dec.tok = endToken
-		return nil, true, nil
 	case d[0] == ',':
-		dec.r.Discard(1)
-		return dec.arrayState, false, nil
+		f.r.Discard(1)
+		return (*frame).arrayValueState, false, nil
+	case d[0] == ']':
+		return f.closeContainer()
 	default:
-		return nil, false, errors.Errorf("Unexpected token %s", d)
+		return nil, false, f.r.unexpected(d, "',' or ']'")
 	}
 }
 
-func (dec *Decoder) boolState() (stateFn, bool, error) {
-	var str bytes.Buffer
+func (f *frame) arrayValueState() (stateFn, bool, error) {
+	d, err := f.peek()
+	if err != nil {
+		return nil, false, err
+	}
 
-	for {
-		b, _, err := dec.r.ReadRune()
-		if err != nil && err != io.EOF {
-			return nil, false, err
-		}
+	if d[0] == ']' && f.r.opts.Syntax != JSON {
+		// trailing comma
+		return f.closeContainer()
+	}
+	return f.elementState()
+}
 
-		str.WriteRune(b)
-		v := str.String()
-		if strings.HasPrefix("true", v) || strings.HasPrefix("false", v) {
-			if v == "true" || v == "false" {
-				dec.tok = boolToken(v)
-				return nil, true, nil
-			}
-			continue
-		}
+// elementState decodes the next element of an array, or skips it if it
+// is not wanted
+func (f *frame) elementState() (stateFn, bool, error) {
+	if f.want == nil {
+		return (*frame).beginState, false, nil
+	}
 
-		return nil, false, errors.Errorf("Unexpected token %s", string(b))
+	if n := f.elemNode(); f.want(&n) {
+		return (*frame).beginState, false, nil
 	}
+	if err := f.r.skipValue(); err != nil {
+		return nil, false, err
+	}
+	f.count++
+	return (*frame).arrayState, false, nil
 }
 
-func (dec *Decoder) nullState() (stateFn, bool, error) {
-	var str bytes.Buffer
+func (f *frame) containerState() (stateFn, bool, error) {
+	if max := f.r.opts.MaxDepth; max > 0 && f.depth >= max {
+		return nil, false, f.r.limitError("MaxDepth", int64(max))
+	}
 
-	for {
-		b, _, err := dec.r.ReadRune()
-		if err != nil && err != io.EOF {
-			return nil, false, err
-		}
+	child := f.r.frameAt(f.depth + 1)
+	*child = frame{tokenInfo: f.info(), r: f.r, depth: f.depth + 1}
+	child.parent = &child.elem
+
+	d, _ := f.r.Peek(1)
+	if d[0] == '{' {
+		child.state = (*frame).objectBeginState
+		f.tok = objectToken{child}
+	} else {
+		child.state, child.array = (*frame).arrayBeginState, true
+		f.tok = arrayToken{child}
+	}
+	f.r.Discard(1)
+	f.r.level++
+	return nil, true, nil
+}
 
-		str.WriteRune(b)
-		v := str.String()
-		if strings.HasPrefix("null", v) {
-			if v == "null" {
-				dec.tok = nullToken
-				return nil, true, nil
-			}
-			continue
-		}
+func (f *frame) boolState() (stateFn, bool, error) {
+	info := f.info()
+
+	d, err := f.peek()
+	if err != nil {
+		return nil, false, err
+	}
+
+	lit := trueText
+	if d[0] == 'f' {
+		lit = falseText
+	}
+
+	if err := f.r.scanLiteral(string(lit)); err != nil {
+		return nil, false, err
+	}
+
+	f.tok = boolToken{f.newScalar(info, lit)}
+	return nil, true, nil
+}
 
-		return nil, false, errors.Errorf("Unexpected token %s", string(b))
+func (f *frame) nullState() (stateFn, bool, error) {
+	info := f.info()
+
+	if err := f.r.scanLiteral("null"); err != nil {
+		return nil, false, err
 	}
+
+	f.tok = nullToken{f.newScalar(info, nullText)}
+	return nil, true, nil
 }
 
-func (dec *Decoder) stringState() (stateFn, bool, error) {
-	dec.r.Discard(1)
+func (f *frame) stringState() (stateFn, bool, error) {
+	info := f.info()
+
+	d, _ := f.r.Peek(1)
+	quote := d[0]
+	f.r.Discard(1)
 
-	str, err := dec.r.ReadString('"')
+	str, err := f.r.scanString(quote)
 	if err != nil {
 		return nil, false, err
 	}
 
-	dec.tok = stringToken(str[0 : len(str)-1])
+	f.tok = stringToken{f.newScalar(info, str)}
 
 	return nil, true, nil
 }
 
-func (dec *Decoder) numberState() (stateFn, bool, error) {
-	var str bytes.Buffer
-	var isFloat bool
+func (f *frame) numberState() (stateFn, bool, error) {
+	info := f.info()
 
-	for {
-		b, _, err := dec.r.ReadRune()
-		if err != nil && err != io.EOF {
-			return nil, false, err
-		}
-		if (b < '0' || b > '9' || err == io.EOF) && b != '.' {
-			dec.r.UnreadRune()
-			if !isFloat {
-				dec.tok = numberToken(str.String())
-			} else {
-				dec.tok = floatToken(str.String())
-			}
-			return nil, true, nil
-		}
+	var num []byte
+	var isFloat bool
+	var err error
+	if f.r.opts.Syntax == JSON5 {
+		num, isFloat, err = f.r.scanNumber5()
+	} else {
+		num, isFloat, err = f.r.scanNumber()
+	}
+	if err != nil {
+		return nil, false, err
+	}
 
-		if b == '.' {
-			isFloat = true
-		}
-		str.WriteRune(b)
+	if !isFloat {
+		f.tok = numberToken{f.newScalar(info, num)}
+	} else {
+		f.tok = floatToken{f.newScalar(info, num)}
 	}
+	return nil, true, nil
 }
 
-func (dec *Decoder) objectBeginState() (stateFn, bool, error) {
-	d, err := dec.r.Peek(1)
+func (f *frame) objectBeginState() (stateFn, bool, error) {
+	d, err := f.peek()
 	if err != nil {
 		return nil, false, err
 	}
 
-	switch {
-	case d[0] == '"':
-		return dec.objectMemberState, false, nil
-	case d[0] == '}':
-		dec.r.Discard(1)
-		dec.tok = endToken
-		return nil, true, nil
-	case d[0] == ' ' || d[0] == '\t' || d[0] == '\n' || d[0] == '\r':
-		dec.r.Discard(1)
-		return dec.objectBeginState, false, nil
-	default:
-		return nil, false, errors.Errorf("Unexpected token %s (objectBeginState)", d)
+	if d[0] == '}' {
+		return f.closeContainer()
 	}
+	return (*frame).objectMemberState, false, nil
 }
 
-func (dec *Decoder) objectState() (stateFn, bool, error) {
-	d, err := dec.r.Peek(1)
+func (f *frame) objectState() (stateFn, bool, error) {
+	d, err := f.peek()
 	if err != nil {
 		return nil, false, err
 	}
 
 	switch {
 	case d[0] == ',':
-		dec.r.Discard(1)
-		return dec.objectMemberState, false, nil
-	case d[0] == '"':
-		return dec.objectMemberState, false, nil
+		f.r.Discard(1)
+		return (*frame).objectMemberState, false, nil
 	case d[0] == '}':
-		dec.r.Discard(1)
-		dec.tok = endToken
-		return nil, true, nil
-	case d[0] == ' ' || d[0] == '\t' || d[0] == '\n' || d[0] == '\r':
-		dec.r.Discard(1)
-		return dec.objectState, false, nil
+		return f.closeContainer()
 	default:
-		return nil, false, errors.Errorf("Unexpected token %s  (objectBeginState)", d)
+		return nil, false, f.r.unexpected(d, "',' or '}'")
 	}
 }
 
-func (dec *Decoder) objectMemberState() (stateFn, bool, error) {
-	for {
-		r, _, err := dec.r.ReadRune()
-		if err != nil {
+func (f *frame) objectMemberState() (stateFn, bool, error) {
+	f.keyed = false
+
+	d, err := f.peek()
+	if err != nil {
+		return nil, false, err
+	}
+	if d[0] == '}' && f.r.opts.Syntax != JSON {
+		// trailing comma
+		return f.closeContainer()
+	}
+
+	pos := f.r.position()
+	key, err := f.scanKey(d[0])
+	if err != nil {
+		if _, ok := err.(*LimitExceededError); ok && key != nil {
+			// a key too long to be read is given by its beginning
+			f.key, f.keyed = string(truncate(key, f.r.opts.MaxStringLength)), true
+		}
+		return nil, false, err
+	}
+	f.key, f.keyed = f.r.intern(key), true
+
+	f.count++
+	if max := f.r.opts.MaxMembersPerObject; max > 0 && f.count > max {
+		return nil, false, &LimitExceededError{Limit: "MaxMembersPerObject", Max: int64(max), Position: pos}
+	}
+
+	if err := f.memberSpace(); err != nil {
+		return nil, false, err
+	}
+	if d, err = f.peek(); err != nil {
+		return nil, false, err
+	}
+	if d[0] != ':' {
+		return nil, false, f.r.unexpected(d, "':'")
+	}
+	f.r.Discard(1)
+
+	if f.want != nil {
+		if n := f.elemNode(); !f.want(&n) {
+			if err := f.r.skipValue(); err != nil {
+				return nil, false, err
+			}
+			return (*frame).objectState, false, nil
+		}
+	}
+
+	// the value is decoded in place rather than by a child decoder
+	var ok bool
+	for state := (*frame).beginState; !ok; {
+		if err := f.memberSpace(); err != nil {
 			return nil, false, err
 		}
-		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
-			continue
+		if state, ok, err = state(f); err != nil {
+			return nil, false, err
 		}
-		if r != '"' {
-			return nil, false, errors.Errorf("Unexpected token %s", string(r))
+		if state == nil {
+			state = (*frame).beginState
 		}
-		break
 	}
-	dec.r.UnreadRune()
+	f.member = MemberToken{tokenInfo: tokenInfo{pos: pos, elem: f.elemNode()}, Key: f.key, Value: f.tok}
+	if f.r.opts.ReuseTokens {
+		f.tok = &f.member
+	} else {
+		member := f.member
+		f.tok = &member
+	}
+	f.state = (*frame).objectState
+	return (*frame).objectState, true, nil
+}
 
-	dec.r.Discard(1)
+// scanKey consumes the key of a member, which is a string, or in JSON5
+// may also be single-quoted or unquoted
+func (f *frame) scanKey(b byte) ([]byte, error) {
+	json5 := f.r.opts.Syntax == JSON5
 
-	key, err := dec.r.ReadString('"')
-	if err != nil {
-		return nil, false, err
+	switch {
+	case b == '"' || (json5 && b == '\''):
+		f.r.Discard(1)
+		return f.r.scanString(b)
+	case json5:
+		return f.r.scanIdent()
+	}
+
+	d, _ := f.r.Peek(1)
+	return nil, f.r.unexpected(d, "'\"'")
+}
+
+// truncate returns the first max bytes of text, or fewer so as not to
+// split a UTF-8 sequence
+func truncate(text []byte, max int) []byte {
+	if len(text) <= max {
+		return text
+	}
+	for max > 0 && !utf8.RuneStart(text[max]) {
+		max--
 	}
+	return text[:max]
+}
+
+// closeContainer consumes the closing brace or bracket of the current
+// object or array and returns its end token
+func (f *frame) closeContainer() (stateFn, bool, error) {
+	f.tok = endToken{f.newScalar(f.endInfo(), nil)}
+	f.r.Discard(1)
+	f.r.level--
+	return nil, true, nil
+}
 
-	// discard whitespace
+// space skips whitespace and, in the JSONC and JSON5 syntaxes,
+// comments. With the Comments option the first comment is returned
+// instead of skipped. Running out of input is left to the caller.
+func (f *frame) space() (Token, error) {
 	for {
-		r, _, err := dec.r.ReadRune()
+		if err := f.r.skipSpace(); err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		if f.r.opts.Syntax == JSON {
+			return nil, nil
+		}
+		if d, _ := f.r.Peek(1); d[0] != '/' {
+			return nil, nil
+		}
+
+		info := f.info()
+		text, err := f.r.scanComment()
 		if err != nil {
-			return nil, false, err
+			return nil, err
 		}
-		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
-			continue
+		if f.r.opts.Comments {
+			if !f.r.opts.ReuseTokens {
+				text = bytes.Clone(text)
+			}
+			return commentToken{&scalar{tokenInfo: info, text: text}}, nil
 		}
-		if r != ':' {
-			return nil, false, errors.Errorf("Unexpected token %s", string(r))
+	}
+}
+
+// memberSpace skips whitespace and comments inside a member, keeping
+// the comments for Next to return after the member
+func (f *frame) memberSpace() error {
+	if f.r.opts.Syntax == JSON {
+		if err := f.r.skipSpace(); err != io.EOF {
+			return err
 		}
-		break
+		return nil
 	}
 
-	dr := newDecoder(dec.r)
-	tok, err := dr.Next()
+	for {
+		comment, err := f.space()
+		if comment == nil {
+			return err
+		}
+		f.pending = append(f.pending, comment)
+	}
+}
+
+// DisallowTrailingData returns an error if anything but whitespace
+// and comments follows the input read so far, including the unread
+// part of a partially read value
+func (dec *Decoder) DisallowTrailingData() error {
+	return dec.disallowTrailingData()
+}
+
+func (f *frame) disallowTrailingData() error {
+	for {
+		comment, err := f.space()
+		if err != nil {
+			return err
+		}
+		if comment == nil {
+			break
+		}
+	}
+
+	d, err := f.r.Peek(1)
+	if err == io.EOF {
+		return nil
+	}
 	if err != nil {
-		return nil, false, err
+		return err
+	}
+	return f.r.unexpected(d, "end of input")
+}
+
+// endValue checks what follows a complete top-level value, in Strict
+// and Sequence modes
+func (f *frame) endValue() error {
+	if f.r.opts.Mode == Sequence {
+		return f.endRecord()
+	}
+	return f.disallowTrailingData()
+}
+
+// end finishes the only value accepted in Strict mode, returning io.EOF
+// if nothing follows it
+func (dec *Decoder) end() error {
+	if err := dec.r.skipTo(0); err != nil {
+		return dec.annotate(err)
+	}
+	if err := dec.disallowTrailingData(); err != nil {
+		return dec.annotate(err)
+	}
+	return io.EOF
+}
+
+// countToken counts the token about to be returned by Next against
+// MaxTokens
+func (f *frame) countToken() error {
+	f.r.tokens++
+	if max := f.r.opts.MaxTokens; max > 0 && f.r.tokens > max && f.tok != nil {
+		return &LimitExceededError{Limit: "MaxTokens", Max: max, Position: f.tok.Position()}
+	}
+	return nil
+}
+
+// peek returns the next byte. Running out of input is only an error
+// inside an object or array.
+func (f *frame) peek() ([]byte, error) {
+	d, err := f.r.Peek(1)
+	if err != nil {
+		return nil, f.eof(err)
+	}
+	return d, nil
+}
+
+// eof turns io.EOF into an UnexpectedEOFError inside an object or
+// array, or before the only value in Strict mode
+func (f *frame) eof(err error) error {
+	if err == io.EOF && (f.depth > 0 || f.r.opts.Mode == Strict) {
+		return f.r.eofError("Unexpected end of input")
+	}
+	return err
+}
+
+// elemNode returns the path of the value being decoded
+func (f *frame) elemNode() pathNode {
+	if f.array {
+		return pathNode{parent: f.parent, kind: indexNode, index: f.count}
+	}
+	if f.keyed {
+		return pathNode{parent: f.parent, kind: keyNode, key: f.key}
+	}
+	return f.parentNode()
+}
+
+// parentNode returns the path of the containing object or array
+func (f *frame) parentNode() pathNode {
+	if f.parent == nil {
+		return pathNode{}
+	}
+	return *f.parent
+}
+
+// elemPath returns the JSON Pointer of the value being decoded
+func (f *frame) elemPath() string {
+	n := f.elemNode()
+	return n.pointer()
+}
+
+// info returns the token metadata for a value starting at the current
+// position
+func (f *frame) info() tokenInfo {
+	return tokenInfo{pos: f.r.position(), elem: f.elemNode()}
+}
+
+// endInfo returns the token metadata for the end of the current object
+// or array
+func (f *frame) endInfo() tokenInfo {
+	return tokenInfo{pos: f.r.position(), elem: f.parentNode()}
+}
+
+// annotate records the current path on errors raised while decoding,
+// and the first error of each record of a sequence
+func (f *frame) annotate(err error) error {
+	return f.annotateAt(err, f.elemPath())
+}
+
+// annotateAt is like annotate, recording path instead
+func (f *frame) annotateAt(err error, path string) error {
+	if f.r.opts.Mode == Sequence && f.r.recordErr == nil && err != io.EOF {
+		f.r.recordErr = err
 	}
 
-	dec.tok = &MemberToken{Key: key[0 : len(key)-1], Value: tok}
-	dec.initialState = dec.objectState
-	return dec.objectState, true, nil
+	switch e := err.(type) {
+	case *SyntaxError:
+		if e.Path == "" {
+			e.Path = path
+		}
+	case *NumberError:
+		if e.Path == "" {
+			e.Path = path
+		}
+	case *UnexpectedEOFError:
+		if e.Path == "" {
+			e.Path = path
+		}
+	case *LimitExceededError:
+		if e.Position.Line == 0 {
+			e.Position = f.r.position()
+		}
+		if e.Path == "" {
+			e.Path = path
+		}
+	}
+	return err
 }
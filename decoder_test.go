@@ -2,6 +2,7 @@ package sjson
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"testing"
@@ -87,6 +88,43 @@ func TestDecoder(t *testing.T) {
 		So(err, ShouldEqual, io.EOF)
 	})
 
+	Convey("A JSON string with escapes should decode to the unescaped text", t, func() {
+		r := strings.NewReader(`"a\"b\\c\/d\n\t\u00e9\ud83d\ude00"`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(t, ShouldNotBeNil)
+		So(t.Type(), ShouldEqual, StringType)
+		So(toString(t), ShouldEqual, "a\"b\\c/d\n\t\u00e9\U0001F600")
+	})
+
+	Convey("A JSON string with a malformed escape should fail to parse", t, func() {
+		for _, s := range []string{`"\x"`, `"\u12G4"`, `"\ud83d"`, `"\ude00"`, `"\ud83d\u0041"`, `"abc`, "\"a\nb\""} {
+			dec := NewDecoder(strings.NewReader(s))
+
+			t, err := dec.Next()
+			So(err, ShouldNotBeNil)
+			So(t, ShouldBeNil)
+		}
+	})
+
+	Convey("A JSON Object with escaped keys should decode the key", t, func() {
+		r := strings.NewReader(`{"a\"b": "c\"d"}`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		t, err = t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, MemberType)
+
+		mt := t.(*MemberToken)
+		So(mt.Key, ShouldEqual, `a"b`)
+		So(toString(mt.Value), ShouldEqual, `c"d`)
+	})
+
 	Convey("A JSON int number should decode to a Number token", t, func() {
 		r := strings.NewReader(`42`)
 		dec := NewDecoder(r)
@@ -123,6 +161,60 @@ func TestDecoder(t *testing.T) {
 		So(err, ShouldEqual, io.EOF)
 	})
 
+	Convey("JSON numbers should follow the number grammar", t, func() {
+		cases := []struct {
+			in  string
+			typ interface{}
+		}{
+			{`-5`, numberToken{}},
+			{`0`, numberToken{}},
+			{`-0.5`, floatToken{}},
+			{`1e10`, floatToken{}},
+			{`2.5E-3`, floatToken{}},
+			{`1E+2`, floatToken{}},
+		}
+
+		for _, c := range cases {
+			dec := NewDecoder(strings.NewReader(c.in))
+
+			t, err := dec.Next()
+			So(err, ShouldBeNil)
+			So(t, ShouldHaveSameTypeAs, c.typ)
+			So(toString(t), ShouldEqual, c.in)
+			So(t.Type(), ShouldEqual, NumberType)
+		}
+	})
+
+	Convey("A JSON negative number should Unmarshal", t, func() {
+		dec := NewDecoder(strings.NewReader(`[-5, -2.5e1]`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		var i int64
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.(SimpleToken).Unmarshal(&i), ShouldBeNil)
+		So(i, ShouldEqual, -5)
+
+		var f float64
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.(SimpleToken).Unmarshal(&f), ShouldBeNil)
+		So(f, ShouldEqual, -25)
+	})
+
+	Convey("Malformed JSON numbers should fail with a NumberError", t, func() {
+		for _, s := range []string{`1.2.3`, `01`, `-`, `-a`, `1.`, `1.e5`, `1e`, `1e+`, `1-2`, `2e3e4`} {
+			dec := NewDecoder(strings.NewReader(s))
+
+			t, err := dec.Next()
+			So(err, ShouldHaveSameTypeAs, &NumberError{})
+			So(t, ShouldBeNil)
+		}
+	})
+
 	Convey("A JSON float64 number should Marshal into a float32 pointer", t, func() {
 		r := strings.NewReader(`42.3`)
 		dec := NewDecoder(r)
@@ -185,11 +277,32 @@ func TestDecoder(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(t, ShouldNotBeNil)
 		So(t.Type(), ShouldEqual, NullType)
+		So(fmt.Sprint(t), ShouldEqual, "null")
 
 		_, err = dec.Next()
 		So(err, ShouldEqual, io.EOF)
 	})
 
+	Convey("Members should print as key=value", t, func() {
+		r := strings.NewReader(`{"a": null, "b": "x", "c": 1.5, "d": true}`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		var out []string
+		ct := t.(ComplexToken)
+		for {
+			t, err = ct.Next()
+			So(err, ShouldBeNil)
+			out = append(out, fmt.Sprint(t))
+			if t.Type() == EndType {
+				break
+			}
+		}
+		So(out, ShouldResemble, []string{"a=null", "b=x", "c=1.5", "d=true", "endToken"})
+	})
+
 	Convey("A JSON Object should decode into an Object token", t, func() {
 		r := strings.NewReader(`{}`)
 		dec := NewDecoder(r)
@@ -308,6 +421,152 @@ func TestDecoder(t *testing.T) {
 		So(t.Type(), ShouldEqual, EndType)
 	})
 
+	Convey("Tokens should report where they began", t, func() {
+		r := strings.NewReader("{\n  \"key\": [1, \"two\"],\n  \"k2\": null\n}")
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(t.Position(), ShouldResemble, Position{Offset: 0, Line: 1, Column: 1})
+
+		ct := t.(ComplexToken)
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.Position(), ShouldResemble, Position{Offset: 4, Line: 2, Column: 3})
+
+		mt := t.(*MemberToken)
+		So(mt.Value.Position(), ShouldResemble, Position{Offset: 11, Line: 2, Column: 10})
+
+		ct2 := mt.Value.(ComplexToken)
+		t, err = ct2.Next()
+		So(err, ShouldBeNil)
+		So(t.Position(), ShouldResemble, Position{Offset: 12, Line: 2, Column: 11})
+
+		t, err = ct2.Next()
+		So(err, ShouldBeNil)
+		So(t.Position(), ShouldResemble, Position{Offset: 15, Line: 2, Column: 14})
+
+		t, err = ct2.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, EndType)
+		So(t.Position(), ShouldResemble, Position{Offset: 20, Line: 2, Column: 19})
+		So(dec.InputOffset(), ShouldEqual, 21)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.Position(), ShouldResemble, Position{Offset: 25, Line: 3, Column: 3})
+		So(t.(*MemberToken).Value.Position(), ShouldResemble, Position{Offset: 31, Line: 3, Column: 9})
+	})
+
+	Convey("Syntax errors should report their position and a snippet", t, func() {
+		r := strings.NewReader("[1,\n 2,\n x]")
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		ct := t.(ComplexToken)
+		_, err = ct.Next()
+		So(err, ShouldBeNil)
+		_, err = ct.Next()
+		So(err, ShouldBeNil)
+
+		_, err = ct.Next()
+		So(err, ShouldHaveSameTypeAs, &SyntaxError{})
+
+		se := err.(*SyntaxError)
+		So(se.Position, ShouldResemble, Position{Offset: 9, Line: 3, Column: 2})
+		So(se.Snippet, ShouldEqual, "[1,\n 2,\n ^x]")
+	})
+
+	Convey("Truncated input should fail with an UnexpectedEOFError", t, func() {
+		for _, s := range []string{`{`, `{"a"`, `{"a":`, `{"a":1`, `[1,`, `"abc`, `tru`, `{"a":[1,{"b":`} {
+			dec := NewDecoder(strings.NewReader(s))
+
+			var err error
+			var walk func(ct ComplexToken)
+			walk = func(ct ComplexToken) {
+				for err == nil {
+					var t Token
+					t, err = ct.Next()
+					if err != nil || t.Type() == EndType {
+						return
+					}
+					if mt, ok := t.(*MemberToken); ok {
+						t = mt.Value
+					}
+					if ct2, ok := t.(ComplexToken); ok {
+						walk(ct2)
+					}
+				}
+			}
+			walk(dec)
+
+			So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+			So(errors.Is(err, ErrSyntax), ShouldBeFalse)
+
+			var eof *UnexpectedEOFError
+			So(errors.As(err, &eof), ShouldBeTrue)
+		}
+	})
+
+	Convey("Invalid input should fail with a SyntaxError carrying the path", t, func() {
+		r := strings.NewReader(`{"a": [1, {"b~/": x}]}`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		t, err = t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+		ct := t.(*MemberToken).Value.(ComplexToken)
+
+		_, err = ct.Next()
+		So(err, ShouldBeNil)
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+
+		_, err = t.(ComplexToken).Next()
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+
+		var se *SyntaxError
+		So(errors.As(err, &se), ShouldBeTrue)
+		So(se.Path, ShouldEqual, "/a/1/b~0~1")
+		So(se.Expected, ShouldEqual, "value")
+		So(se.Found, ShouldEqual, "x")
+	})
+
+	Convey("Unmarshalling into the wrong type should fail with an UnmarshalTypeError", t, func() {
+		dec := NewDecoder(strings.NewReader(`["str", 300, true, null]`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		var i int
+		t, _ = ct.Next()
+		err = t.(SimpleToken).Unmarshal(&i)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+		So(err.(*UnmarshalTypeError).Path, ShouldEqual, "/0")
+
+		var i8 int8
+		t, _ = ct.Next()
+		err = t.(SimpleToken).Unmarshal(&i8)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+
+		var s string
+		t, _ = ct.Next()
+		err = t.(SimpleToken).Unmarshal(&s)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+
+		err = t.(SimpleToken).Unmarshal(s)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+
+		t, _ = ct.Next()
+		err = t.(SimpleToken).Unmarshal(&s)
+		So(errors.Is(err, ErrUnmarshalType), ShouldBeTrue)
+	})
+
 	Convey("A JSON Array should decode into an Array token", t, func() {
 		r := strings.NewReader(`[]`)
 		dec := NewDecoder(r)
@@ -457,6 +716,91 @@ func TestDecoder(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(t.Type(), ShouldEqual, EndType)
 	})
+
+	Convey("Tokens should stay valid once the decoder reads on", t, func() {
+		dec := NewDecoder(strings.NewReader(`[1, "two", [3], [4]]`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		t1, err := ct.Next()
+		So(err, ShouldBeNil)
+		t2, err := ct.Next()
+		So(err, ShouldBeNil)
+		So(toString(t1), ShouldEqual, "1")
+		So(toString(t2), ShouldEqual, "two")
+
+		t3, err := ct.Next()
+		So(err, ShouldBeNil)
+		t4, err := ct.Next()
+		So(err, ShouldBeNil)
+		So(t3.Pointer(), ShouldEqual, "/2")
+		So(t4.Pointer(), ShouldEqual, "/3")
+
+		t, err = t4.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+		So(toString(t), ShouldEqual, "4")
+
+		// the parent moved past [3] before it was read
+		_, err = t3.(ComplexToken).Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("ReuseTokens should reuse the tokens of the decoder", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(`[1, 2]`), DecoderOptions{ReuseTokens: true})
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		t1, err := ct.Next()
+		So(err, ShouldBeNil)
+		So(toString(t1), ShouldEqual, "1")
+
+		t2, err := ct.Next()
+		So(err, ShouldBeNil)
+		So(toString(t2), ShouldEqual, "2")
+		So(toString(t1), ShouldEqual, "2")
+	})
+
+	Convey("Reading the parent of a partly read array should skip the rest of the array", t, func() {
+		for _, opts := range []DecoderOptions{{}, {ReuseTokens: true}} {
+			dec := NewDecoderWithOptions(strings.NewReader(`{"a": [1, [2, 3], 4], "b": 5}`), opts)
+
+			t, err := dec.Next()
+			So(err, ShouldBeNil)
+			ct := t.(ComplexToken)
+
+			t, err = ct.Next()
+			So(err, ShouldBeNil)
+			inner := t.(*MemberToken).Value.(ComplexToken)
+
+			t, err = inner.Next()
+			So(err, ShouldBeNil)
+			So(toString(t), ShouldEqual, "1")
+
+			t, err = inner.Next()
+			So(err, ShouldBeNil)
+			So(t.Type(), ShouldEqual, ArrayType)
+
+			t, err = ct.Next()
+			So(err, ShouldBeNil)
+			So(t.Type(), ShouldEqual, MemberType)
+			So(t.(*MemberToken).Key, ShouldEqual, "b")
+			So(toString(t.(*MemberToken).Value), ShouldEqual, "5")
+
+			_, err = inner.Next()
+			So(err, ShouldEqual, io.EOF)
+
+			t, err = ct.Next()
+			So(err, ShouldBeNil)
+			So(t.Type(), ShouldEqual, EndType)
+
+			_, err = dec.Next()
+			So(err, ShouldEqual, io.EOF)
+		}
+	})
 }
 
 func toString(t Token) string {
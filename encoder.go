@@ -0,0 +1,347 @@
+package sjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// An encFrame is an object or array the encoder is inside of
+type encFrame struct {
+	object bool
+	count  int
+
+	// key is set between writing a member key and its value
+	key bool
+}
+
+// An Encoder writes JSON tokens to an output stream. It validates the
+// nesting of objects and arrays and inserts the commas and colons
+// between values. Top-level values are separated by newlines.
+type Encoder struct {
+	w     *bufio.Writer
+	stack []encFrame
+
+	// prefix and indent are set by SetIndent
+	prefix, indent string
+	indented       bool
+
+	buf []byte
+}
+
+// NewEncoder creates a new encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// WriteToken writes a token read from a Decoder. Simple tokens are
+// written as values. Object and array tokens only begin the value; their
+// children must be written after them, followed by the EndType token.
+// A MemberToken writes its key, and its value too if it is a simple
+// token. Comments are left out, and the JSON5 numbers Infinity and NaN
+// are rejected with ErrUnsupportedValue.
+func (enc *Encoder) WriteToken(t Token) error {
+	switch t.Type() {
+	case CommentType:
+		return nil
+	case ObjectType:
+		return enc.BeginObject()
+	case ArrayType:
+		return enc.BeginArray()
+	case EndType:
+		return enc.End()
+	case MemberType:
+		mt := t.(*MemberToken)
+		if err := enc.Key(mt.Key); err != nil {
+			return err
+		}
+		if mt.Value.Type() < ObjectType {
+			return enc.WriteToken(mt.Value)
+		}
+		return nil
+	case StringType:
+		return enc.String(toText(t))
+	case NumberType, BoolType, NullType:
+		text := toText(t)
+		if text == "" {
+			text = fmt.Sprintf("%s", t)
+		}
+		if t.Type() == NumberType && nonFinite(text) {
+			return fmt.Errorf("%w: %s at %s", ErrUnsupportedValue, text, t.Position())
+		}
+		return enc.value(text, false)
+	}
+
+	return fmt.Errorf("%w: unknown token type %s", ErrInvalidNesting, t.Type())
+}
+
+// nonFinite tells whether the number text is a JSON5 Infinity or NaN,
+// which JSON cannot represent
+func nonFinite(text string) bool {
+	return strings.HasSuffix(text, "Infinity") || text == "NaN"
+}
+
+// Copy writes t and, for objects, arrays and members with complex
+// values, everything up to and including the matching EndType token
+func (enc *Encoder) Copy(t Token) error {
+	if err := enc.WriteToken(t); err != nil {
+		return err
+	}
+
+	if mt, ok := t.(*MemberToken); ok {
+		if mt.Value.Type() < ObjectType {
+			return nil
+		}
+		return enc.Copy(mt.Value)
+	}
+
+	ct, ok := t.(ComplexToken)
+	if !ok {
+		return nil
+	}
+
+	for {
+		t, err := ct.Next()
+		if err != nil {
+			return err
+		}
+		if t.Type() == EndType {
+			return enc.End()
+		}
+		if err := enc.Copy(t); err != nil {
+			return err
+		}
+	}
+}
+
+// SetIndent makes the encoder begin each element of an object or array
+// on a new line, starting with prefix followed by one copy of indent
+// for each level of nesting. Top-level values do not begin with prefix.
+// An empty prefix and indent turn indentation off again.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix, enc.indent = prefix, indent
+	enc.indented = prefix != "" || indent != ""
+}
+
+// newline starts a new line indented for the current nesting level
+func (enc *Encoder) newline() {
+	if !enc.indented {
+		return
+	}
+	enc.w.WriteByte('\n')
+	enc.w.WriteString(enc.prefix)
+	for range enc.stack {
+		enc.w.WriteString(enc.indent)
+	}
+}
+
+// BeginObject starts a new object
+func (enc *Encoder) BeginObject() error {
+	if err := enc.value("{", true); err != nil {
+		return err
+	}
+	enc.stack = append(enc.stack, encFrame{object: true})
+	return nil
+}
+
+// BeginArray starts a new array
+func (enc *Encoder) BeginArray() error {
+	if err := enc.value("[", true); err != nil {
+		return err
+	}
+	enc.stack = append(enc.stack, encFrame{})
+	return nil
+}
+
+// Key writes the key of the next member of the current object
+func (enc *Encoder) Key(key string) error {
+	if len(enc.stack) == 0 || !enc.stack[len(enc.stack)-1].object {
+		return fmt.Errorf("%w: key %q outside of an object", ErrInvalidNesting, key)
+	}
+
+	f := &enc.stack[len(enc.stack)-1]
+	if f.key {
+		return fmt.Errorf("%w: key %q written before the value of the previous key", ErrInvalidNesting, key)
+	}
+	if f.count > 0 {
+		enc.w.WriteByte(',')
+	}
+	enc.newline()
+
+	enc.buf = appendString(enc.buf[:0], key)
+	enc.buf = append(enc.buf, ':')
+	if enc.indented {
+		enc.buf = append(enc.buf, ' ')
+	}
+	enc.w.Write(enc.buf)
+
+	f.key = true
+	return nil
+}
+
+// End ends the current object or array
+func (enc *Encoder) End() error {
+	if len(enc.stack) == 0 {
+		return fmt.Errorf("%w: end outside of an object or array", ErrInvalidNesting)
+	}
+
+	f := enc.stack[len(enc.stack)-1]
+	if f.key {
+		return fmt.Errorf("%w: end of object after a key without a value", ErrInvalidNesting)
+	}
+
+	enc.stack = enc.stack[:len(enc.stack)-1]
+	if f.count > 0 {
+		enc.newline()
+	}
+	if f.object {
+		enc.w.WriteByte('}')
+	} else {
+		enc.w.WriteByte(']')
+	}
+
+	return enc.endValue()
+}
+
+// String writes a string value
+func (enc *Encoder) String(s string) error {
+	enc.buf = appendString(enc.buf[:0], s)
+	return enc.value(string(enc.buf), false)
+}
+
+// Int writes an integer value
+func (enc *Encoder) Int(i int64) error {
+	return enc.value(strconv.FormatInt(i, 10), false)
+}
+
+// Float writes a floating point value. NaN and infinities cannot be
+// represented in JSON and are rejected.
+func (enc *Encoder) Float(f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%w: unsupported float value %v", ErrUnsupportedValue, f)
+	}
+	return enc.value(formatFloat(f), false)
+}
+
+// Bool writes a boolean value
+func (enc *Encoder) Bool(b bool) error {
+	return enc.value(strconv.FormatBool(b), false)
+}
+
+// Null writes a null value
+func (enc *Encoder) Null() error {
+	return enc.value("null", false)
+}
+
+// Flush writes any buffered output to the underlying writer. It is
+// called automatically after each complete top-level value.
+func (enc *Encoder) Flush() error {
+	return enc.w.Flush()
+}
+
+// value writes the value text s in the current context. begin is set
+// for the opening bracket of an object or array.
+func (enc *Encoder) value(s string, begin bool) error {
+	if len(enc.stack) > 0 {
+		f := &enc.stack[len(enc.stack)-1]
+		if f.object && !f.key {
+			return fmt.Errorf("%w: value %s in an object without a key", ErrInvalidNesting, s)
+		}
+		if !f.object && f.count > 0 {
+			enc.w.WriteByte(',')
+		}
+		if !f.object {
+			enc.newline()
+		}
+	}
+
+	enc.w.WriteString(s)
+
+	if begin {
+		return nil
+	}
+	return enc.endValue()
+}
+
+// endValue records a completed value in the current context
+func (enc *Encoder) endValue() error {
+	if len(enc.stack) == 0 {
+		enc.w.WriteByte('\n')
+		return enc.w.Flush()
+	}
+
+	f := &enc.stack[len(enc.stack)-1]
+	f.count++
+	f.key = false
+	return nil
+}
+
+// formatFloat formats f the way encoding/json does
+func formatFloat(f float64) string {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	return strconv.FormatFloat(f, format, -1, 64)
+}
+
+const hex = "0123456789abcdef"
+
+// appendString appends s to dst as a quoted JSON string. Only quotes,
+// backslashes and control characters are escaped; invalid UTF-8 is
+// replaced with U+FFFD.
+func appendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\uFFFD"...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
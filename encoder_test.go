@@ -0,0 +1,123 @@
+package sjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEncoder(t *testing.T) {
+
+	Convey("The encoder should write nested values with separators", t, func() {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+
+		So(enc.BeginObject(), ShouldBeNil)
+		So(enc.Key("a"), ShouldBeNil)
+		So(enc.Int(1), ShouldBeNil)
+		So(enc.Key("b"), ShouldBeNil)
+		So(enc.BeginArray(), ShouldBeNil)
+		So(enc.String("x\"y\n"), ShouldBeNil)
+		So(enc.Float(2.5), ShouldBeNil)
+		So(enc.Bool(true), ShouldBeNil)
+		So(enc.Null(), ShouldBeNil)
+		So(enc.BeginObject(), ShouldBeNil)
+		So(enc.End(), ShouldBeNil)
+		So(enc.End(), ShouldBeNil)
+		So(enc.End(), ShouldBeNil)
+		So(enc.String("next"), ShouldBeNil)
+
+		So(buf.String(), ShouldEqual, "{\"a\":1,\"b\":[\"x\\\"y\\n\",2.5,true,null,{}]}\n\"next\"\n")
+	})
+
+	Convey("The encoder should reject invalid nesting", t, func() {
+		enc := NewEncoder(io.Discard)
+
+		So(errors.Is(enc.Key("a"), ErrInvalidNesting), ShouldBeTrue)
+		So(errors.Is(enc.End(), ErrInvalidNesting), ShouldBeTrue)
+
+		So(enc.BeginObject(), ShouldBeNil)
+		So(errors.Is(enc.Int(1), ErrInvalidNesting), ShouldBeTrue)
+		So(enc.Key("a"), ShouldBeNil)
+		So(errors.Is(enc.Key("b"), ErrInvalidNesting), ShouldBeTrue)
+		So(errors.Is(enc.End(), ErrInvalidNesting), ShouldBeTrue)
+
+		So(enc.BeginArray(), ShouldBeNil)
+		So(errors.Is(enc.Key("c"), ErrInvalidNesting), ShouldBeTrue)
+	})
+
+	Convey("The encoder should escape strings", t, func() {
+		So(string(appendString(nil, "a\x01\t\\é\xff")), ShouldEqual, `"a\u0001\t\\é`+"�"+`"`)
+	})
+
+	Convey("The encoder should copy decoded tokens back out", t, func() {
+		in := `{"a": [1, -2.5e3, "s\"q", {"b": null}], "c": true, "d": {}}`
+		dec := NewDecoder(strings.NewReader(in))
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(enc.Copy(t), ShouldBeNil)
+
+		So(buf.String(), ShouldEqual, `{"a":[1,-2.5e3,"s\"q",{"b":null}],"c":true,"d":{}}`+"\n")
+	})
+
+	Convey("The encoder should accept the token stream from ReadAll", t, func() {
+		in := `{"a": [1, {"b": "c"}], "d": 2}`
+
+		ch := make(chan Token)
+		go func() {
+			defer close(ch)
+			ReadAll(strings.NewReader(in), ch)
+		}()
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		for t := range ch {
+			So(enc.WriteToken(t), ShouldBeNil)
+		}
+
+		So(buf.String(), ShouldEqual, `{"a":[1,{"b":"c"}],"d":2}`+"\n")
+	})
+
+	Convey("The encoder should reject JSON5 numbers that JSON cannot represent", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(`[Infinity, -Infinity, NaN, 0x1F]`), DecoderOptions{Syntax: JSON5})
+		enc := NewEncoder(io.Discard)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(enc.WriteToken(t), ShouldBeNil)
+
+		ct := t.(ComplexToken)
+		for i := 0; i < 3; i++ {
+			t, err = ct.Next()
+			So(err, ShouldBeNil)
+			So(errors.Is(enc.WriteToken(t), ErrUnsupportedValue), ShouldBeTrue)
+		}
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(enc.WriteToken(t), ShouldBeNil)
+	})
+	Convey("The encoder should indent nested values", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"a": [1, {"b": null}, []], "c": {}} [true]`))
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetIndent("> ", "  ")
+
+		for i := 0; i < 2; i++ {
+			t, err := dec.Next()
+			So(err, ShouldBeNil)
+			So(enc.Copy(t), ShouldBeNil)
+		}
+
+		So(buf.String(), ShouldEqual, "{\n>   \"a\": [\n>     1,\n>     {\n>       \"b\": null\n>     },\n>     []\n>   ],\n>   \"c\": {}\n> }\n[\n>   true\n> ]\n")
+	})
+}
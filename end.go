@@ -1,13 +1,13 @@
 package sjson
 
-var endToken _endToken
-
-type _endToken struct{}
+type endToken struct {
+	*scalar
+}
 
-func (n _endToken) Type() Type {
+func (n endToken) Type() Type {
 	return EndType
 }
 
-func (n _endToken) String() string {
+func (n endToken) String() string {
 	return "endToken"
 }
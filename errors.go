@@ -0,0 +1,121 @@
+package sjson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Sentinel errors for use with errors.Is. Every error returned by the
+// decoder unwraps to one of these, or to io.ErrUnexpectedEOF for
+// truncated input.
+var (
+	ErrSyntax        = errors.New("sjson: syntax error")
+	ErrUnmarshalType = errors.New("sjson: cannot unmarshal value")
+	ErrLimitExceeded = errors.New("sjson: limit exceeded")
+)
+
+// Errors returned by the Encoder
+var (
+	ErrInvalidNesting   = errors.New("sjson: invalid nesting")
+	ErrUnsupportedValue = errors.New("sjson: unsupported value")
+)
+
+// Errors returned by Find and Seek
+var (
+	ErrInvalidPointer = errors.New("sjson: invalid JSON pointer")
+	ErrNotFound       = errors.New("sjson: value not found")
+)
+
+// ErrInvalidJSONPath is returned by CompileJSONPath for invalid queries
+var ErrInvalidJSONPath = errors.New("sjson: invalid JSONPath")
+
+// A SyntaxError is returned when the input is not valid JSON. It
+// records where the problem was found and a snippet of the surrounding
+// input, with a caret marking the position.
+type SyntaxError struct {
+	Msg string
+
+	// Expected describes what the decoder was looking for and Found is
+	// the input it saw instead. Either may be empty.
+	Expected string
+	Found    string
+
+	Position Position
+	Path     string
+	Snippet  string
+}
+
+func (e *SyntaxError) Error() string {
+	msg := e.Msg
+	if e.Expected != "" {
+		msg += fmt.Sprintf(" (expected %s)", e.Expected)
+	}
+	return fmt.Sprintf("%s at %s%s near %q", msg, e.Position, pathSuffix(e.Path), e.Snippet)
+}
+
+// Unwrap returns ErrSyntax
+func (e *SyntaxError) Unwrap() error {
+	return ErrSyntax
+}
+
+// An UnexpectedEOFError is returned when the input ends in the middle
+// of a value. It distinguishes truncated input from invalid input.
+type UnexpectedEOFError struct {
+	Msg      string
+	Position Position
+	Path     string
+}
+
+func (e *UnexpectedEOFError) Error() string {
+	return fmt.Sprintf("%s at %s%s", e.Msg, e.Position, pathSuffix(e.Path))
+}
+
+// Unwrap returns io.ErrUnexpectedEOF
+func (e *UnexpectedEOFError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// An UnmarshalTypeError is returned when a JSON value cannot be stored
+// in the Go value it is being unmarshalled into
+type UnmarshalTypeError struct {
+	Value    string
+	Type     reflect.Type
+	Position Position
+	Path     string
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("Cannot unmarshal JSON %s into Go value of type %s at %s%s", e.Value, e.Type, e.Position, pathSuffix(e.Path))
+}
+
+// Unwrap returns ErrUnmarshalType
+func (e *UnmarshalTypeError) Unwrap() error {
+	return ErrUnmarshalType
+}
+
+// A LimitExceededError is returned when the input exceeds one of the
+// decoder's resource limits
+type LimitExceededError struct {
+	Limit    string
+	Max      int64
+	Position Position
+	Path     string
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s of %d exceeded at %s%s", e.Limit, e.Max, e.Position, pathSuffix(e.Path))
+}
+
+// Unwrap returns ErrLimitExceeded
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+func pathSuffix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return " in " + path
+}
@@ -0,0 +1,109 @@
+package sjson
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A field is a struct field that takes part in decoding and encoding
+type field struct {
+	name  string
+	index []int
+	typ   reflect.Type
+
+	omitEmpty bool
+	quoted    bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields returns the fields of the struct type t, honoring
+// `json:"name,omitempty,string"` tags
+func cachedFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t, nil))
+	return f.([]field)
+}
+
+func typeFields(t reflect.Type, index []int) []field {
+	var fields []field
+	var embedded []field
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name, opts = tag[:idx], tag[idx:]
+		}
+
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		ft := sf.Type
+		if sf.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, typeFields(ft, idx)...)
+				continue
+			}
+		}
+
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, field{
+			name:      name,
+			index:     idx,
+			typ:       sf.Type,
+			omitEmpty: strings.Contains(opts, ",omitempty"),
+			quoted:    strings.Contains(opts, ",string"),
+		})
+	}
+
+	// promoted fields lose to fields of the same name declared directly
+	// on the struct; names that only differ in case are both kept, for
+	// lookupField to prefer the exact match
+	declared := len(fields)
+	for _, ef := range embedded {
+		same := func(f field) bool { return f.name == ef.name }
+		if !slices.ContainsFunc(fields[:declared], same) {
+			fields = append(fields, ef)
+		}
+	}
+
+	return fields
+}
+
+// lookupField finds the field for the member key, preferring an exact
+// match over a case-insensitive one
+func lookupField(fields []field, key string) *field {
+	var fold *field
+	for i := range fields {
+		if fields[i].name == key {
+			return &fields[i]
+		}
+		if fold == nil && strings.EqualFold(fields[i].name, key) {
+			fold = &fields[i]
+		}
+	}
+	return fold
+}
@@ -0,0 +1,335 @@
+package sjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A FormatOption configures Indent and Compact
+type FormatOption func(*formatter)
+
+// SortKeys sorts the members of objects by key, comparing bytes, when
+// the formatted members of the object take at most size bytes. Larger
+// objects keep their members in input order: their members are written
+// out as soon as they go past size, so memory use stays bounded by
+// about size bytes for each level of nesting.
+func SortKeys(size int) FormatOption {
+	return func(f *formatter) {
+		f.sortSize = size
+	}
+}
+
+// MaxWidth makes Indent write arrays of simple values on one line, such
+// as [1, 2, 3], when the line is at most width bytes long
+func MaxWidth(width int) FormatOption {
+	return func(f *formatter) {
+		f.width = width
+	}
+}
+
+// FormatDecoderOptions sets the options of the decoder reading the
+// input, such as its syntax and limits. Comments are left out of the
+// output.
+func FormatDecoderOptions(opts DecoderOptions) FormatOption {
+	return func(f *formatter) {
+		f.opts = opts
+	}
+}
+
+// Indent reads the JSON values in src and writes them to dst with each
+// element of an object or array on a new line, beginning with prefix
+// followed by one copy of indent for each level of nesting. Each value
+// is followed by a newline and does not begin with prefix. Numbers are
+// written exactly as they appear in the input, and strings with the
+// minimal escaping of the Encoder.
+//
+// The input is streamed: only the objects being sorted by SortKeys and
+// the arrays that may fit within MaxWidth are held in memory.
+func Indent(dst io.Writer, src io.Reader, prefix, indent string, opts ...FormatOption) error {
+	f := &formatter{prefix: prefix, indent: indent, indented: true}
+	return f.format(dst, src, opts)
+}
+
+// Compact reads the JSON values in src and writes them to dst without
+// insignificant whitespace, each followed by a newline. See Indent.
+func Compact(dst io.Writer, src io.Reader, opts ...FormatOption) error {
+	f := &formatter{}
+	return f.format(dst, src, opts)
+}
+
+// formatter writes the values of a decoder, keeping track of the column
+// to fit arrays within the width
+type formatter struct {
+	prefix, indent string
+	indented       bool
+
+	sortSize int
+	width    int
+	opts     DecoderOptions
+
+	// canonical selects the output of Canonicalize
+	canonical bool
+
+	// w is the output, or the sortBuffer of the object being sorted
+	out *bufio.Writer
+	w   io.Writer
+	col int
+
+	buf []byte
+}
+
+func (f *formatter) format(dst io.Writer, src io.Reader, opts []FormatOption) error {
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	f.out = bufio.NewWriter(dst)
+	f.w = f.out
+
+	dec := NewDecoderWithOptions(src, f.opts)
+	for {
+		tok, err := nextValue(dec)
+		if err == io.EOF {
+			return f.out.Flush()
+		}
+		if err == nil {
+			err = f.value(tok, 0)
+		}
+		if err != nil {
+			f.out.Flush()
+			return err
+		}
+		if !f.canonical {
+			f.write("\n")
+		}
+	}
+}
+
+// write writes s to the output
+func (f *formatter) write(s string) {
+	io.WriteString(f.w, s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		f.col = len(s) - i - 1
+	} else {
+		f.col += len(s)
+	}
+}
+
+// newline returns the text that begins a line at depth
+func (f *formatter) newline(depth int) string {
+	if !f.indented {
+		return ""
+	}
+	return "\n" + f.prefix + strings.Repeat(f.indent, depth)
+}
+
+func (f *formatter) value(tok Token, depth int) error {
+	switch tok.Type() {
+	case ObjectType:
+		return f.object(tok.(ComplexToken), depth)
+	case ArrayType:
+		return f.array(tok.(ComplexToken), depth)
+	}
+
+	text, err := f.scalar(tok)
+	if err != nil {
+		return err
+	}
+	f.write(text)
+	return nil
+}
+
+// scalar returns the text of the simple token tok
+func (f *formatter) scalar(tok Token) (string, error) {
+	text := toText(tok)
+	switch tok.Type() {
+	case StringType:
+		f.buf = appendString(f.buf[:0], text)
+		return string(f.buf), nil
+	case NumberType:
+		if f.canonical {
+			return canonicalNumber(tok, text)
+		}
+		if nonFinite(text) {
+			return "", fmt.Errorf("%w: %s at %s", ErrUnsupportedValue, text, tok.Position())
+		}
+	}
+	return text, nil
+}
+
+func (f *formatter) object(ct ComplexToken, depth int) error {
+	f.write("{")
+
+	var sb *sortBuffer
+	if f.sortSize > 0 || f.canonical {
+		sb = &sortBuffer{f: f, parent: f.w, depth: depth}
+		f.w = sb
+	}
+
+	n := 0
+	for {
+		tok, err := nextValue(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == EndType {
+			break
+		}
+
+		mt := tok.(*MemberToken)
+		if sb != nil && !sb.spilled {
+			// separators are written along with the sorted members
+			sb.members = append(sb.members, sortMember{key: mt.Key})
+			f.col = len(strings.TrimPrefix(f.newline(depth+1), "\n"))
+		} else {
+			if n > 0 {
+				f.write(",")
+			}
+			f.write(f.newline(depth + 1))
+		}
+
+		f.buf = appendString(f.buf[:0], mt.Key)
+		f.buf = append(f.buf, ':')
+		if f.indented {
+			f.buf = append(f.buf, ' ')
+		}
+		f.write(string(f.buf))
+
+		if err := f.value(mt.Value, depth+1); err != nil {
+			return err
+		}
+		n++
+	}
+
+	if sb != nil {
+		f.w = sb.parent
+		if !sb.spilled {
+			sort.SliceStable(sb.members, func(i, j int) bool {
+				if f.canonical {
+					return lessUTF16(sb.members[i].key, sb.members[j].key)
+				}
+				return sb.members[i].key < sb.members[j].key
+			})
+			sb.spill()
+		}
+	}
+
+	if n > 0 {
+		f.write(f.newline(depth))
+	}
+	f.write("}")
+	return nil
+}
+
+func (f *formatter) array(ct ComplexToken, depth int) error {
+	f.write("[")
+
+	// elements are held back while the array may fit on one line, with
+	// room left for the closing bracket
+	oneLine := f.indented && f.width > 0
+	var pending []string
+	size := f.col + 1
+
+	n := 0
+	for {
+		tok, err := nextValue(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == EndType {
+			break
+		}
+
+		if oneLine {
+			if typ := tok.Type(); typ != ObjectType && typ != ArrayType {
+				text, err := f.scalar(tok)
+				if err != nil {
+					return err
+				}
+				pending = append(pending, text)
+				if size += len(text) + 2; size-2 <= f.width {
+					continue
+				}
+			}
+
+			oneLine = false
+			for _, text := range pending {
+				if n > 0 {
+					f.write(",")
+				}
+				f.write(f.newline(depth+1) + text)
+				n++
+			}
+			if typ := tok.Type(); typ != ObjectType && typ != ArrayType {
+				continue
+			}
+		}
+
+		if n > 0 {
+			f.write(",")
+		}
+		f.write(f.newline(depth + 1))
+		if err := f.value(tok, depth+1); err != nil {
+			return err
+		}
+		n++
+	}
+
+	if oneLine {
+		f.write(strings.Join(pending, ", ") + "]")
+		return nil
+	}
+	if n > 0 {
+		f.write(f.newline(depth))
+	}
+	f.write("]")
+	return nil
+}
+
+// A sortBuffer holds the formatted members of an object until they can
+// be sorted, or until they grow past the size limit and are written out
+// in input order
+type sortBuffer struct {
+	f       *formatter
+	parent  io.Writer
+	depth   int
+	members []sortMember
+	size    int
+	spilled bool
+}
+
+type sortMember struct {
+	key  string
+	text []byte
+}
+
+func (sb *sortBuffer) Write(p []byte) (int, error) {
+	if sb.spilled {
+		return sb.parent.Write(p)
+	}
+
+	m := &sb.members[len(sb.members)-1]
+	m.text = append(m.text, p...)
+	if sb.size += len(p); sb.size > sb.f.sortSize && !sb.f.canonical {
+		sb.spill()
+	}
+	return len(p), nil
+}
+
+// spill writes the members held so far to the parent, with their
+// separators; anything written after that goes straight to the parent
+func (sb *sortBuffer) spill() {
+	newline := sb.f.newline(sb.depth + 1)
+	for i, m := range sb.members {
+		if i > 0 {
+			io.WriteString(sb.parent, ",")
+		}
+		io.WriteString(sb.parent, newline)
+		sb.parent.Write(m.text)
+	}
+	sb.members = nil
+	sb.spilled = true
+}
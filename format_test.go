@@ -0,0 +1,94 @@
+package sjson
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFormat(t *testing.T) {
+
+	indent := func(in string, opts ...FormatOption) (string, error) {
+		var buf bytes.Buffer
+		err := Indent(&buf, strings.NewReader(in), "", "  ", opts...)
+		return buf.String(), err
+	}
+
+	compact := func(in string, opts ...FormatOption) (string, error) {
+		var buf bytes.Buffer
+		err := Compact(&buf, strings.NewReader(in), opts...)
+		return buf.String(), err
+	}
+
+	Convey("Indent should put each element on its own line", t, func() {
+		out, err := indent(`{"a": [1, {"b": null}, []], "c": {}, "d": "é\t"} 1.50e+3`)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, "{\n  \"a\": [\n    1,\n    {\n      \"b\": null\n    },\n    []\n  ],\n  \"c\": {},\n  \"d\": \"é\\t\"\n}\n1.50e+3\n")
+
+		var buf bytes.Buffer
+		So(Indent(&buf, strings.NewReader(`[1]`), "//", "\t"), ShouldBeNil)
+		So(buf.String(), ShouldEqual, "[\n//\t1\n//]\n")
+	})
+
+	Convey("Compact should remove whitespace and keep number text", t, func() {
+		out, err := compact("{ \"a\" : [ 1 , -0.0 , 1E400 ] ,\n \"b\" : { } }\n[ ]")
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, "{\"a\":[1,-0.0,1E400],\"b\":{}}\n[]\n")
+	})
+
+	Convey("SortKeys should sort the keys of objects within the size", t, func() {
+		in := `{"b": 1, "a": {"d": [2], "c": 3}, "a": 0}`
+
+		out, err := compact(in, SortKeys(100))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"a":{"c":3,"d":[2]},"a":0,"b":1}`+"\n")
+
+		out, err = indent(in, SortKeys(100))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, "{\n  \"a\": {\n    \"c\": 3,\n    \"d\": [\n      2\n    ]\n  },\n  \"a\": 0,\n  \"b\": 1\n}\n")
+
+		// the outer object is too large to sort, the inner one is not
+		out, err = compact(in, SortKeys(12))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"b":1,"a":{"c":3,"d":[2]},"a":0}`+"\n")
+
+		out, err = compact(`{"z": {"y": [1, 2, 3, 4, 5, 6], "x": 1}, "w": 2}`, SortKeys(8))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"z":{"y":[1,2,3,4,5,6],"x":1},"w":2}`+"\n")
+	})
+
+	Convey("MaxWidth should keep short arrays on one line", t, func() {
+		in := `{"short": [1, 2, "x"], "long": [100, 200, 300], "nested": [[1], 2], "empty": []}`
+
+		out, err := indent(in, MaxWidth(24))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, "{\n"+
+			"  \"short\": [1, 2, \"x\"],\n"+
+			"  \"long\": [\n    100,\n    200,\n    300\n  ],\n"+
+			"  \"nested\": [\n    [1],\n    2\n  ],\n"+
+			"  \"empty\": []\n"+
+			"}\n")
+
+		out, err = indent(`{"b": [1, 2], "a": [3]}`, MaxWidth(20), SortKeys(100))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, "{\n  \"a\": [3],\n  \"b\": [1, 2]\n}\n")
+	})
+
+	Convey("Relaxed input should be reformatted as JSON", t, func() {
+		out, err := compact("// c\n{a: 0x10, 'b': .5,}", FormatDecoderOptions(DecoderOptions{Syntax: JSON5}))
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"a":16,"b":0.5}`+"\n")
+
+		_, err = compact(`[Infinity]`, FormatDecoderOptions(DecoderOptions{Syntax: JSON5}))
+		So(errors.Is(err, ErrUnsupportedValue), ShouldBeTrue)
+	})
+
+	Convey("Errors should stop the output", t, func() {
+		out, err := compact(`[1] {"a": }`)
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+		So(out, ShouldEqual, "[1]\n{")
+	})
+}
@@ -1,3 +1,11 @@
 module github.com/sheenobu/sjson
 
-require github.com/pkg/errors v0.8.1
+go 1.23
+
+require github.com/smartystreets/goconvey v1.7.2
+
+require (
+	github.com/gopherjs/gopherjs v0.0.0-20181017120253-0766667cb4d1 // indirect
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v1.2.0 // indirect
+)
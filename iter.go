@@ -0,0 +1,151 @@
+package sjson
+
+import (
+	"io"
+	"iter"
+)
+
+// An ObjectToken is the token for a JSON object
+type ObjectToken interface {
+	ComplexToken
+
+	// Members iterates over the remaining members of the object
+	Members() iter.Seq2[string, Token]
+
+	// Err returns the first error encountered by Members
+	Err() error
+}
+
+// An ArrayToken is the token for a JSON array
+type ArrayToken interface {
+	ComplexToken
+
+	// Elements iterates over the remaining elements of the array
+	Elements() iter.Seq2[int, Token]
+
+	// Err returns the first error encountered by Elements
+	Err() error
+}
+
+// All iterates over the remaining top-level values, leaving out
+// comments. Objects and arrays that are left partly read by the loop
+// body are skipped before the next value is read, including when the
+// loop is broken out of. The iteration ends at the end of the input or
+// at the first error, which is yielded with a nil Token. In Sequence
+// mode, a record found to be damaged while skipping it is dropped
+// instead.
+func (dec *Decoder) All() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for {
+			t, err := dec.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if t.Type() == CommentType {
+				continue
+			}
+
+			ok := yield(t, nil)
+			if err := discard(t); err != nil && !(dec.r.opts.Mode == Sequence && damaged(err)) {
+				if ok {
+					yield(nil, err)
+				}
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Members iterates over the remaining members of the object, yielding
+// each key and value. Values left partly read by the loop body are
+// skipped; breaking out of the loop skips the rest of the object. Check
+// Err after the loop.
+func (o objectToken) Members() iter.Seq2[string, Token] {
+	return func(yield func(string, Token) bool) {
+		for {
+			t, err := o.Next()
+			if err != nil {
+				o.setErr(err)
+				return
+			}
+			if t.Type() == EndType {
+				return
+			}
+			if t.Type() == CommentType {
+				continue
+			}
+
+			mt := t.(*MemberToken)
+			if !yield(mt.Key, mt.Value) {
+				o.setErr(o.Skip())
+				return
+			}
+			if err := discard(mt.Value); err != nil {
+				o.setErr(err)
+				return
+			}
+		}
+	}
+}
+
+// Err returns the first error encountered by Members
+func (o objectToken) Err() error {
+	return o.err
+}
+
+func (o objectToken) setErr(err error) {
+	if o.err == nil && err != io.EOF {
+		o.err = err
+	}
+}
+
+// Elements iterates over the remaining elements of the array, yielding
+// each index and value. Values left partly read by the loop body are
+// skipped; breaking out of the loop skips the rest of the array. Check
+// Err after the loop.
+func (a arrayToken) Elements() iter.Seq2[int, Token] {
+	return func(yield func(int, Token) bool) {
+		for {
+			i := a.count
+
+			t, err := a.Next()
+			if err != nil {
+				a.setErr(err)
+				return
+			}
+			if t.Type() == EndType {
+				return
+			}
+			if t.Type() == CommentType {
+				continue
+			}
+
+			if !yield(i, t) {
+				a.setErr(a.Skip())
+				return
+			}
+			if err := discard(t); err != nil {
+				a.setErr(err)
+				return
+			}
+		}
+	}
+}
+
+// Err returns the first error encountered by Elements
+func (a arrayToken) Err() error {
+	return a.err
+}
+
+func (a arrayToken) setErr(err error) {
+	if a.err == nil && err != io.EOF {
+		a.err = err
+	}
+}
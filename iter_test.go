@@ -0,0 +1,102 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIter(t *testing.T) {
+
+	Convey("Members should yield every key and value", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"a": 1, "b": [2, 3], "c": {"d": 4}, "e": "f"}`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		obj := t.(ObjectToken)
+
+		var keys []string
+		for k, v := range obj.Members() {
+			keys = append(keys, k)
+			if k == "e" {
+				So(toString(v), ShouldEqual, "f")
+			}
+		}
+		So(obj.Err(), ShouldBeNil)
+		So(keys, ShouldResemble, []string{"a", "b", "c", "e"})
+
+		_, err = dec.Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Breaking out of Elements should skip the rest of the array", t, func() {
+		dec := NewDecoder(strings.NewReader(`[[10, 11, [12]], 20, 30] "after"`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		arr := t.(ArrayToken)
+
+		var seen []int
+		for i, v := range arr.Elements() {
+			seen = append(seen, i)
+			if i == 0 {
+				for j := range v.(ArrayToken).Elements() {
+					if j == 1 {
+						break
+					}
+				}
+			}
+			if i == 1 {
+				break
+			}
+		}
+		So(arr.Err(), ShouldBeNil)
+		So(seen, ShouldResemble, []int{0, 1})
+
+		t, err = dec.Next()
+		So(err, ShouldBeNil)
+		So(toString(t), ShouldEqual, "after")
+	})
+
+	Convey("All should yield top-level values and skip unread subtrees", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"a": [1]} [2, 3] 4`))
+
+		var types []Type
+		for t, err := range dec.All() {
+			So(err, ShouldBeNil)
+			types = append(types, t.Type())
+		}
+		So(types, ShouldResemble, []Type{ObjectType, ArrayType, NumberType})
+	})
+
+	Convey("All should stop at the first error", t, func() {
+		dec := NewDecoder(strings.NewReader(`1 [2, `))
+
+		var errs []error
+		for _, err := range dec.All() {
+			errs = append(errs, err)
+		}
+		So(len(errs), ShouldEqual, 3)
+		So(errs[0], ShouldBeNil)
+		So(errs[1], ShouldBeNil)
+		So(errors.Is(errs[2], io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+
+	Convey("Members should report errors through Err", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"a": 1, "b" 2}`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		obj := t.(ObjectToken)
+
+		n := 0
+		for range obj.Members() {
+			n++
+		}
+		So(n, ShouldEqual, 1)
+		So(errors.Is(obj.Err(), ErrSyntax), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,304 @@
+package sjson
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"unicode"
+	"unicode/utf8"
+)
+
+// space5 returns the length of the JSON5 whitespace character beginning
+// d, which is not JSON whitespace, or 0
+func space5(d []byte) int {
+	if d[0] == '\v' || d[0] == '\f' {
+		return 1
+	}
+	if d[0] < utf8.RuneSelf || !utf8.FullRune(d) {
+		return 0
+	}
+
+	c, n := utf8.DecodeRune(d)
+	if unicode.Is(unicode.Zs, c) || c == '\u2028' || c == '\u2029' || c == '\ufeff' {
+		return n
+	}
+	return 0
+}
+
+// skipSpace5 consumes JSON5 whitespace, which adds more characters to
+// that of JSON
+func (r *reader) skipSpace5() error {
+	for {
+		d, err := r.window()
+		if err != nil {
+			return err
+		}
+
+		i := 0
+		for i < len(d) {
+			if b := d[i]; b == ' ' || b == '\t' || b == '\n' || b == '\r' {
+				i++
+			} else if n := space5(d[i:]); n > 0 {
+				i += n
+			} else {
+				break
+			}
+		}
+		r.Discard(i)
+
+		if i < len(d) {
+			// a JSON5 space may be cut off at the end of the window
+			if d, _ := r.Peek(utf8.UTFMax); space5(d) == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// isIdentStart tells whether c may begin an unquoted JSON5 key
+func isIdentStart(c rune) bool {
+	return c == '$' || c == '_' || c == '\\' || unicode.IsLetter(c) || unicode.Is(unicode.Nl, c)
+}
+
+// isIdentPart tells whether c may continue an unquoted JSON5 key
+func isIdentPart(c rune) bool {
+	return isIdentStart(c) || unicode.IsDigit(c) ||
+		unicode.In(c, unicode.Mn, unicode.Mc, unicode.Pc) ||
+		c == '\u200c' || c == '\u200d'
+}
+
+// peekRune returns the next rune without consuming it
+func (r *reader) peekRune() (rune, int, error) {
+	d, err := r.Peek(utf8.UTFMax)
+	if len(d) == 0 {
+		return 0, 0, err
+	}
+
+	c, n := utf8.DecodeRune(d)
+	return c, n, nil
+}
+
+// scanIdent consumes an unquoted JSON5 key and returns its name.
+// Unicode escapes are allowed. Like scanString, it fails with the name
+// read so far if the name is longer than MaxStringLength.
+func (r *reader) scanIdent() ([]byte, error) {
+	var name []byte
+
+	for {
+		c, n, err := r.peekRune()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if n == 0 || !isIdentPart(c) || (len(name) == 0 && !isIdentStart(c)) {
+			break
+		}
+
+		if c == '\\' {
+			d, _ := r.Peek(2)
+			if len(d) < 2 || d[1] != 'u' {
+				return nil, r.syntaxErrorf("Invalid escape sequence in key")
+			}
+			r.Discard(2)
+
+			c, err = readHex4(r)
+			if err != nil {
+				return nil, err
+			}
+			if c == '\\' || !isIdentPart(c) || (len(name) == 0 && !isIdentStart(c)) {
+				return nil, r.syntaxErrorf("Invalid character %q in key", c)
+			}
+			name = utf8.AppendRune(name, c)
+		} else {
+			r.Discard(n)
+			name = utf8.AppendRune(name, c)
+		}
+
+		if max := r.opts.MaxStringLength; max > 0 && len(name) > max {
+			return name, r.limitError("MaxStringLength", int64(max))
+		}
+	}
+
+	if len(name) == 0 {
+		d, _ := r.Peek(1)
+		return nil, r.unexpected(d, "key")
+	}
+	return name, nil
+}
+
+// maxHexDigits bounds the digits of a JSON5 hexadecimal number, whose
+// conversion to decimal takes more than linear time
+const maxHexDigits = 256
+
+// scanNumber5 consumes a JSON5 number and returns its value as JSON
+// number text, and whether it is a float. Hexadecimal numbers are
+// converted to decimal, a leading '+' is dropped and bare decimal
+// points get a zero. Infinity and NaN are returned as they are.
+func (r *reader) scanNumber5() ([]byte, bool, error) {
+	pos := r.position()
+
+	var text []byte
+	for {
+		d, err := r.Peek(1)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		b := d[0]
+		if !(b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '.' || b == '+' || b == '-') {
+			break
+		}
+		r.Discard(1)
+		text = append(text, b)
+
+		if max := r.opts.MaxNumberLength; max > 0 && len(text) > max {
+			return nil, false, r.limitError("MaxNumberLength", int64(max))
+		}
+	}
+
+	fail := func(msg string) ([]byte, bool, error) {
+		return nil, false, &NumberError{Num: string(text), Msg: msg, Position: pos}
+	}
+
+	var sign []byte
+	s := text
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		if s[0] == '-' {
+			sign = []byte{'-'}
+		}
+		s = s[1:]
+	}
+
+	switch {
+	case string(s) == "Infinity":
+		return append(sign, s...), true, nil
+	case string(s) == "NaN":
+		return s, true, nil
+	case len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X'):
+		if len(s)-2 > maxHexDigits {
+			return fail("hexadecimal number too long")
+		}
+		var n big.Int
+		if _, ok := n.SetString(string(s[2:]), 16); !ok || s[2] == '+' || s[2] == '-' {
+			return fail("invalid hexadecimal number")
+		}
+		return append(sign, n.String()...), false, nil
+	}
+
+	// decimal: int? ('.' frac?)? exponent?, with at least one digit
+	i := 0
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+
+	num := append([]byte(nil), sign...)
+	isFloat := false
+
+	intDigits := digits()
+	if intDigits > 1 && s[0] == '0' {
+		return fail("leading zero")
+	}
+	if intDigits == 0 {
+		num = append(num, '0')
+	}
+	num = append(num, s[:i]...)
+
+	if i < len(s) && s[i] == '.' {
+		isFloat = true
+		i++
+		start := i
+		if digits() == 0 {
+			if intDigits == 0 {
+				return fail("expected digit")
+			}
+			num = append(num, ".0"...)
+		} else {
+			num = append(num, '.')
+			num = append(num, s[start:i]...)
+		}
+	} else if intDigits == 0 {
+		return fail("expected digit")
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		isFloat = true
+		start := i
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return fail("expected digit in exponent")
+		}
+		num = append(num, s[start:i]...)
+	}
+
+	if i < len(s) {
+		return fail("unexpected character")
+	}
+	return num, isFloat, nil
+}
+
+// readEscape5 decodes the JSON5 escape sequences missing from JSON,
+// b being the byte following the backslash. Any other character stands
+// for itself, and an escaped line terminator continues the string on
+// the next line.
+func readEscape5(r *reader, b byte, str *bytes.Buffer) error {
+	switch {
+	case b == 'v':
+		str.WriteByte('\v')
+	case b == '0':
+		if d, err := r.Peek(1); err == nil && d[0] >= '0' && d[0] <= '9' {
+			return r.syntaxErrorf("Invalid escape sequence \\0%c in string", d[0])
+		}
+		str.WriteByte(0)
+	case b >= '1' && b <= '9':
+		return r.syntaxErrorf("Invalid escape sequence \\%c in string", b)
+	case b == 'x':
+		d, err := r.Peek(2)
+		if err == io.EOF {
+			return r.eofError("Unexpected end of input in string")
+		}
+		if err != nil {
+			return err
+		}
+
+		var c rune
+		for _, h := range d {
+			switch {
+			case h >= '0' && h <= '9':
+				c = c<<4 | rune(h-'0')
+			case h >= 'a' && h <= 'f':
+				c = c<<4 | rune(h-'a'+10)
+			case h >= 'A' && h <= 'F':
+				c = c<<4 | rune(h-'A'+10)
+			default:
+				return r.syntaxErrorf("Invalid escape sequence \\x%s in string", d)
+			}
+		}
+		r.Discard(2)
+		str.WriteRune(c)
+	case b == '\n':
+	case b == '\r':
+		if d, err := r.Peek(1); err == nil && d[0] == '\n' {
+			r.Discard(1)
+		}
+	case b == 0xE2:
+		// U+2028 and U+2029 are line terminators too
+		if d, err := r.Peek(2); err == nil && d[0] == 0x80 && (d[1] == 0xA8 || d[1] == 0xA9) {
+			r.Discard(2)
+			return nil
+		}
+		str.WriteByte(b)
+	default:
+		str.WriteByte(b)
+	}
+
+	return nil
+}
@@ -0,0 +1,611 @@
+package sjson
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A JSONPath is a compiled JSONPath query (RFC 9535). It is safe for
+// concurrent use.
+type JSONPath struct {
+	expr string
+	segs []segment
+}
+
+// A segment selects children of the nodes it is applied to, or with
+// descendant set, of those nodes and all their descendants
+type segment struct {
+	descendant bool
+	sels       []selector
+}
+
+// kinds of selector
+const (
+	nameSelector = iota
+	wildcardSelector
+	indexSelector
+	sliceSelector
+	filterSelector
+)
+
+type selector struct {
+	kind uint8
+
+	name  string
+	index int
+
+	// slice bounds; start and end may be omitted
+	start, end, step int
+	hasStart, hasEnd bool
+
+	filter logical
+}
+
+// maxIndex is the largest index allowed by RFC 9535, the largest
+// integer exactly representable as a float64
+const maxIndex = 1<<53 - 1
+
+// CompileJSONPath parses a JSONPath query. Filter expressions may not
+// refer to the root node $, as they could not be evaluated in a single
+// pass over the input.
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	p := &pathParser{s: expr}
+
+	if !p.consume("$") {
+		return nil, p.errorf("expected '$'")
+	}
+	segs, err := p.segments()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.i:])
+	}
+
+	return &JSONPath{expr: expr, segs: segs}, nil
+}
+
+// MustCompileJSONPath is like CompileJSONPath but panics if the query
+// is invalid
+func MustCompileJSONPath(expr string) *JSONPath {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source text of the query
+func (p *JSONPath) String() string {
+	return p.expr
+}
+
+// pathParser parses JSONPath queries
+type pathParser struct {
+	s string
+	i int
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d of %q", ErrInvalidJSONPath, fmt.Sprintf(format, args...), p.i, p.s)
+}
+
+// peek returns the next byte, or 0 at the end
+func (p *pathParser) peek() byte {
+	if p.i < len(p.s) {
+		return p.s[p.i]
+	}
+	return 0
+}
+
+// consume consumes s if it comes next
+func (p *pathParser) consume(s string) bool {
+	if strings.HasPrefix(p.s[p.i:], s) {
+		p.i += len(s)
+		return true
+	}
+	return false
+}
+
+// blank skips spaces, tabs and line breaks
+func (p *pathParser) blank() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// segments parses the segments following a root or current node
+// identifier. Blanks after the last segment are left unread.
+func (p *pathParser) segments() ([]segment, error) {
+	var segs []segment
+	for {
+		start := p.i
+		p.blank()
+		if c := p.peek(); c != '.' && c != '[' {
+			p.i = start
+			return segs, nil
+		}
+
+		seg, err := p.segment()
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+}
+
+func (p *pathParser) segment() (segment, error) {
+	var seg segment
+
+	switch {
+	case p.consume(".."):
+		seg.descendant = true
+		if p.peek() == '[' {
+			return seg, p.bracketed(&seg)
+		}
+	case p.consume("."):
+	default:
+		return seg, p.bracketed(&seg)
+	}
+
+	if p.consume("*") {
+		seg.sels = []selector{{kind: wildcardSelector}}
+		return seg, nil
+	}
+
+	name := p.memberName()
+	if name == "" {
+		return seg, p.errorf("expected member name or '*'")
+	}
+	seg.sels = []selector{{kind: nameSelector, name: name}}
+	return seg, nil
+}
+
+// memberName parses the name of a .name shorthand, or returns ""
+func (p *pathParser) memberName() string {
+	start := p.i
+	for p.i < len(p.s) {
+		c, n := utf8.DecodeRuneInString(p.s[p.i:])
+		first := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= 0x80 && c <= 0xD7FF) || (c >= 0xE000 && c != utf8.RuneError)
+		if !first && (p.i == start || c < '0' || c > '9') {
+			break
+		}
+		p.i += n
+	}
+	return p.s[start:p.i]
+}
+
+// bracketed parses a bracketed list of selectors
+func (p *pathParser) bracketed(seg *segment) error {
+	p.i++ // '['
+
+	for {
+		p.blank()
+		sel, err := p.selector()
+		if err != nil {
+			return err
+		}
+		seg.sels = append(seg.sels, sel)
+
+		p.blank()
+		switch {
+		case p.consume(","):
+		case p.consume("]"):
+			return nil
+		default:
+			return p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *pathParser) selector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.stringLiteral()
+		return selector{kind: nameSelector, name: name}, err
+	case c == '*':
+		p.i++
+		return selector{kind: wildcardSelector}, nil
+	case c == '?':
+		p.i++
+		p.blank()
+		filter, err := p.logicalOr()
+		return selector{kind: filterSelector, filter: filter}, err
+	}
+
+	sel := selector{kind: indexSelector, step: 1}
+
+	var err error
+	if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+		if sel.start, err = p.integer(); err != nil {
+			return sel, err
+		}
+		sel.index, sel.hasStart = sel.start, true
+	}
+
+	p.blank()
+	if !p.consume(":") {
+		if !sel.hasStart {
+			return sel, p.errorf("expected selector")
+		}
+		return sel, nil
+	}
+
+	sel.kind = sliceSelector
+	p.blank()
+	if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+		if sel.end, err = p.integer(); err != nil {
+			return sel, err
+		}
+		sel.hasEnd = true
+		p.blank()
+	}
+	if p.consume(":") {
+		p.blank()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			if sel.step, err = p.integer(); err != nil {
+				return sel, err
+			}
+		}
+	}
+	return sel, nil
+}
+
+// integer parses an index or slice bound, which has no leading zeros
+func (p *pathParser) integer() (int, error) {
+	start := p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	digits := p.i
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.i++
+	}
+
+	text := p.s[start:p.i]
+	if p.i == digits || (p.s[digits] == '0' && (p.i-digits > 1 || digits > start)) {
+		p.i = start
+		return 0, p.errorf("invalid integer %q", text)
+	}
+
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n > maxIndex || n < -maxIndex {
+		p.i = start
+		return 0, p.errorf("integer %s out of range", text)
+	}
+	return int(n), nil
+}
+
+// stringLiteral parses a single- or double-quoted string
+func (p *pathParser) stringLiteral() (string, error) {
+	quote := p.s[p.i]
+	p.i++
+
+	var b strings.Builder
+	for {
+		if p.i >= len(p.s) {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.s[p.i]
+		switch {
+		case c == quote:
+			p.i++
+			return b.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			b.WriteByte(c)
+			p.i++
+			continue
+		}
+
+		p.i++
+		switch e := p.peek(); e {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '/', '\\', quote:
+			b.WriteByte(e)
+		case 'u':
+			p.i++
+			r, err := p.hex4()
+			if err != nil {
+				return "", err
+			}
+			if r >= 0xD800 && r <= 0xDBFF {
+				if !p.consume(`\u`) {
+					return "", p.errorf("unpaired surrogate")
+				}
+				lo, err := p.hex4()
+				if err != nil {
+					return "", err
+				}
+				if lo < 0xDC00 || lo > 0xDFFF {
+					return "", p.errorf("unpaired surrogate")
+				}
+				r = 0x10000 + (r-0xD800)<<10 + (lo - 0xDC00)
+			} else if r >= 0xDC00 && r <= 0xDFFF {
+				return "", p.errorf("unpaired surrogate")
+			}
+			b.WriteRune(r)
+			continue
+		default:
+			return "", p.errorf("invalid escape sequence")
+		}
+		p.i++
+	}
+}
+
+// hex4 parses the four hexadecimal digits of a \u escape
+func (p *pathParser) hex4() (rune, error) {
+	if p.i+4 > len(p.s) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	n, err := strconv.ParseUint(p.s[p.i:p.i+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	p.i += 4
+	return rune(n), nil
+}
+
+// hasFilter tells whether the segment has a filter, which needs the
+// values of the children
+func (seg *segment) hasFilter() bool {
+	for i := range seg.sels {
+		if seg.sels[i].kind == filterSelector {
+			return true
+		}
+	}
+	return false
+}
+
+// selects tells whether the segment selects the child at node of an
+// object or array with length elements, -1 if unknown. The value of
+// the child is only needed by filters.
+func (seg *segment) selects(node *pathNode, length int, value interface{}) bool {
+	for i := range seg.sels {
+		if seg.sels[i].selects(node, length, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sel *selector) selects(node *pathNode, length int, value interface{}) bool {
+	switch sel.kind {
+	case nameSelector:
+		return node.kind == keyNode && node.key == sel.name
+	case wildcardSelector:
+		return true
+	case indexSelector:
+		i := sel.index
+		if i < 0 {
+			i += length
+		}
+		return node.kind == indexNode && node.index == i
+	case sliceSelector:
+		if node.kind != indexNode || sel.step == 0 {
+			return false
+		}
+		lower, upper := sel.bounds(length)
+		if sel.step > 0 {
+			return node.index >= lower && node.index < upper && (node.index-lower)%sel.step == 0
+		}
+		return node.index <= upper && node.index > lower && (upper-node.index)%-sel.step == 0
+	case filterSelector:
+		return sel.filter.test(value)
+	}
+	return false
+}
+
+// needsLength tells whether the selector depends on the length of the
+// array, which is then only known at its end
+func (sel *selector) needsLength() bool {
+	switch sel.kind {
+	case indexSelector:
+		return sel.index < 0
+	case sliceSelector:
+		return sel.step < 0 || sel.start < 0 || sel.end < 0
+	}
+	return false
+}
+
+// tail returns the number of elements at the end of an array that the
+// selector may select, or -1 if it may select any element
+func (sel *selector) tail() int {
+	switch sel.kind {
+	case indexSelector:
+		if sel.index < 0 {
+			return -sel.index
+		}
+	case sliceSelector:
+		if sel.step > 0 && sel.hasStart && sel.start < 0 {
+			return -sel.start
+		}
+		if sel.step < 0 && sel.hasEnd && sel.end < 0 {
+			return -sel.end - 1
+		}
+	}
+	return -1
+}
+
+// reversed tells whether the selector selects elements from the end of
+// the array backwards
+func (sel *selector) reversed() bool {
+	return sel.kind == sliceSelector && sel.step < 0
+}
+
+// done tells whether the selector can select no more children of an
+// object or array with length elements, -1 if unknown, after the one at
+// index last, -1 before the first. Name selectors are done on arrays
+// only.
+func (sel *selector) done(array bool, last, length int) bool {
+	switch sel.kind {
+	case nameSelector:
+		return array
+	case indexSelector:
+		i := sel.index
+		if i < 0 {
+			i += length
+		}
+		return !array || last >= i
+	case sliceSelector:
+		if !array || sel.step == 0 {
+			return true
+		}
+		lower, upper := sel.bounds(length)
+		if sel.step > 0 {
+			return last >= upper-1 || lower >= upper
+		}
+		return last >= upper
+	}
+	return false
+}
+
+// bounds returns the range of indices of a slice, as in RFC 9535: the
+// indices from lower up to but not including upper for a positive step,
+// and from upper down to but not including lower for a negative one.
+// A length of -1 is only allowed when it is not needed.
+func (sel *selector) bounds(length int) (lower, upper int) {
+	norm := func(i int) int {
+		if i < 0 {
+			return i + length
+		}
+		return i
+	}
+	clamp := func(i, lo, hi int) int {
+		return min(max(i, lo), hi)
+	}
+
+	if sel.step > 0 {
+		start, end := 0, math.MaxInt
+		if length >= 0 {
+			end = length
+		}
+		if sel.hasStart {
+			start = norm(sel.start)
+		}
+		if sel.hasEnd {
+			end = norm(sel.end)
+		}
+		if length < 0 {
+			return max(start, 0), max(end, 0)
+		}
+		return clamp(start, 0, length), clamp(end, 0, length)
+	}
+
+	start, end := length-1, -length-1
+	if sel.hasStart {
+		start = norm(sel.start)
+	}
+	if sel.hasEnd {
+		end = norm(sel.end)
+	}
+	return clamp(end, -1, length-1), clamp(start, -1, length-1)
+}
+
+// apply applies the segment to nodes decoded as by DecodeToken into an
+// interface{}. Object members are visited in key order.
+func (seg *segment) apply(nodes []interface{}) []interface{} {
+	var out []interface{}
+
+	var visit func(v interface{})
+	visit = func(v interface{}) {
+		for i := range seg.sels {
+			out = seg.sels[i].apply(v, out)
+		}
+		if !seg.descendant {
+			return
+		}
+		for _, c := range children(v) {
+			visit(c)
+		}
+	}
+
+	for _, v := range nodes {
+		visit(v)
+	}
+	return out
+}
+
+// apply appends the children of v selected by sel to out
+func (sel *selector) apply(v interface{}, out []interface{}) []interface{} {
+	switch sel.kind {
+	case nameSelector:
+		if m, ok := v.(map[string]interface{}); ok {
+			if c, ok := m[sel.name]; ok {
+				out = append(out, c)
+			}
+		}
+	case wildcardSelector:
+		out = append(out, children(v)...)
+	case indexSelector:
+		if a, ok := v.([]interface{}); ok {
+			i := sel.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				out = append(out, a[i])
+			}
+		}
+	case sliceSelector:
+		a, ok := v.([]interface{})
+		if !ok || sel.step == 0 {
+			break
+		}
+		lower, upper := sel.bounds(len(a))
+		if sel.step > 0 {
+			for i := lower; i < upper; i += sel.step {
+				out = append(out, a[i])
+			}
+		} else {
+			for i := upper; i > lower; i += sel.step {
+				out = append(out, a[i])
+			}
+		}
+	case filterSelector:
+		for _, c := range children(v) {
+			if sel.filter.test(c) {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+// children returns the elements of an array, or the member values of an
+// object in key order
+func children(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := make([]interface{}, len(keys))
+		for i, k := range keys {
+			out[i] = v[k]
+		}
+		return out
+	}
+	return nil
+}
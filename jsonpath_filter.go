@@ -0,0 +1,538 @@
+package sjson
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// logical is a filter expression that is true or false for a node
+type logical interface {
+	test(cur interface{}) bool
+}
+
+// valueExpr is a filter expression that has a value for a node, which
+// may be nothing
+type valueExpr interface {
+	value(cur interface{}) interface{}
+}
+
+// nothingType is the value of a singular query that selects no node
+type nothingType struct{}
+
+var nothing interface{} = nothingType{}
+
+type orExpr []logical
+
+func (e orExpr) test(cur interface{}) bool {
+	for _, x := range e {
+		if x.test(cur) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logical
+
+func (e andExpr) test(cur interface{}) bool {
+	for _, x := range e {
+		if !x.test(cur) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	x logical
+}
+
+func (e notExpr) test(cur interface{}) bool {
+	return !e.x.test(cur)
+}
+
+type compareExpr struct {
+	op          string
+	left, right valueExpr
+}
+
+func (e compareExpr) test(cur interface{}) bool {
+	a, b := e.left.value(cur), e.right.value(cur)
+
+	switch e.op {
+	case "==":
+		return reflect.DeepEqual(a, b)
+	case "!=":
+		return !reflect.DeepEqual(a, b)
+	case "<":
+		return less(a, b)
+	case "<=":
+		return less(a, b) || reflect.DeepEqual(a, b)
+	case ">":
+		return less(b, a)
+	default:
+		return less(b, a) || reflect.DeepEqual(a, b)
+	}
+}
+
+// less orders numbers and strings; other values are not ordered
+func less(a, b interface{}) bool {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		return ok && a < b
+	case string:
+		b, ok := b.(string)
+		return ok && a < b
+	}
+	return false
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l literal) value(cur interface{}) interface{} {
+	return l.v
+}
+
+// A filterQuery is a query relative to the current node @
+type filterQuery struct {
+	segs []segment
+}
+
+// nodes returns the nodes selected from cur
+func (q *filterQuery) nodes(cur interface{}) []interface{} {
+	nodes := []interface{}{cur}
+	for i := range q.segs {
+		nodes = q.segs[i].apply(nodes)
+	}
+	return nodes
+}
+
+// value returns the node selected by a singular query, or nothing
+func (q *filterQuery) value(cur interface{}) interface{} {
+	if nodes := q.nodes(cur); len(nodes) == 1 {
+		return nodes[0]
+	}
+	return nothing
+}
+
+// test tells whether the query selects any node
+func (q *filterQuery) test(cur interface{}) bool {
+	return len(q.nodes(cur)) > 0
+}
+
+// singular tells whether the query selects at most one node
+func (q *filterQuery) singular() bool {
+	for _, seg := range q.segs {
+		if seg.descendant || len(seg.sels) != 1 {
+			return false
+		}
+		if k := seg.sels[0].kind; k != nameSelector && k != indexSelector {
+			return false
+		}
+	}
+	return true
+}
+
+// types of function parameters and results
+const (
+	valueType = iota
+	logicalType
+	nodesType
+)
+
+var functions = map[string]struct {
+	params []uint8
+	result uint8
+}{
+	"length": {[]uint8{valueType}, valueType},
+	"count":  {[]uint8{nodesType}, valueType},
+	"match":  {[]uint8{valueType, valueType}, logicalType},
+	"search": {[]uint8{valueType, valueType}, logicalType},
+	"value":  {[]uint8{nodesType}, valueType},
+}
+
+type funcExpr struct {
+	name   string
+	args   []interface{}
+	result uint8
+
+	// re is the compiled pattern of match and search when it is a
+	// literal, and bad is set if it is invalid
+	re  *regexp.Regexp
+	bad bool
+}
+
+func (f *funcExpr) value(cur interface{}) interface{} {
+	switch f.name {
+	case "length":
+		switch v := f.args[0].(valueExpr).value(cur).(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v))
+		case []interface{}:
+			return float64(len(v))
+		case map[string]interface{}:
+			return float64(len(v))
+		}
+	case "count":
+		return float64(len(f.args[0].(*filterQuery).nodes(cur)))
+	case "value":
+		if nodes := f.args[0].(*filterQuery).nodes(cur); len(nodes) == 1 {
+			return nodes[0]
+		}
+	}
+	return nothing
+}
+
+func (f *funcExpr) test(cur interface{}) bool {
+	s, ok := f.args[0].(valueExpr).value(cur).(string)
+	if !ok || f.bad {
+		return false
+	}
+
+	re := f.re
+	if re == nil {
+		pattern, ok := f.args[1].(valueExpr).value(cur).(string)
+		if !ok {
+			return false
+		}
+
+		var err error
+		if re, err = compileIRegexp(pattern, f.name == "match"); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(s)
+}
+
+// compileIRegexp compiles an I-Regexp (RFC 9485), matching the whole
+// string if full is set. Its '.' does not match line breaks.
+func compileIRegexp(pattern string, full bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if full {
+		b.WriteString(`^(?:`)
+	}
+
+	escaped, class := false, false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '[':
+			class = true
+		case c == ']':
+			class = false
+		case c == '.' && !class:
+			b.WriteString(`[^\n\r]`)
+			continue
+		}
+		b.WriteRune(c)
+	}
+
+	if full {
+		b.WriteString(`)$`)
+	}
+	return regexp.Compile(b.String())
+}
+
+func (p *pathParser) logicalOr() (logical, error) {
+	x, err := p.logicalAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	or := orExpr{x}
+	for {
+		start := p.i
+		p.blank()
+		if !p.consume("||") {
+			p.i = start
+			break
+		}
+		p.blank()
+
+		if x, err = p.logicalAnd(); err != nil {
+			return nil, err
+		}
+		or = append(or, x)
+	}
+
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *pathParser) logicalAnd() (logical, error) {
+	x, err := p.basic()
+	if err != nil {
+		return nil, err
+	}
+
+	and := andExpr{x}
+	for {
+		start := p.i
+		p.blank()
+		if !p.consume("&&") {
+			p.i = start
+			break
+		}
+		p.blank()
+
+		if x, err = p.basic(); err != nil {
+			return nil, err
+		}
+		and = append(and, x)
+	}
+
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+// basic parses a parenthesized expression, a comparison or a test,
+// optionally negated
+func (p *pathParser) basic() (logical, error) {
+	neg := p.consume("!")
+	if neg {
+		p.blank()
+	}
+
+	var x logical
+	if p.consume("(") {
+		p.blank()
+		var err error
+		if x, err = p.logicalOr(); err != nil {
+			return nil, err
+		}
+		p.blank()
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+	} else {
+		start := p.i
+		left, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+
+		if !neg {
+			end := p.i
+			p.blank()
+			if op := p.compareOp(); op != "" {
+				return p.comparison(start, left, op)
+			}
+			p.i = end
+		}
+
+		var ok bool
+		if x, ok = asTest(left); !ok {
+			p.i = start
+			return nil, p.errorf("expected a query or a function returning a logical value")
+		}
+	}
+
+	if neg {
+		return notExpr{x}, nil
+	}
+	return x, nil
+}
+
+// comparison parses the right-hand side of a comparison
+func (p *pathParser) comparison(start int, left interface{}, op string) (logical, error) {
+	p.blank()
+	rstart := p.i
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	l, ok := comparable(left)
+	if !ok {
+		p.i = start
+		return nil, p.errorf("cannot compare a non-singular query or a logical value")
+	}
+	r, ok := comparable(right)
+	if !ok {
+		p.i = rstart
+		return nil, p.errorf("cannot compare a non-singular query or a logical value")
+	}
+	return compareExpr{op: op, left: l, right: r}, nil
+}
+
+func (p *pathParser) compareOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// comparable returns x if it can be compared: a literal, a singular
+// query or a function returning a value
+func comparable(x interface{}) (valueExpr, bool) {
+	switch x := x.(type) {
+	case literal:
+		return x, true
+	case *filterQuery:
+		return x, x.singular()
+	case *funcExpr:
+		return x, x.result == valueType
+	}
+	return nil, false
+}
+
+// asTest returns x if it can be used as a test: a query or a function
+// returning a logical value
+func asTest(x interface{}) (logical, bool) {
+	switch x := x.(type) {
+	case *filterQuery:
+		return x, true
+	case *funcExpr:
+		return x, x.result == logicalType
+	}
+	return nil, false
+}
+
+// operand parses a literal, a query or a function call
+func (p *pathParser) operand() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '@':
+		p.i++
+		segs, err := p.segments()
+		return &filterQuery{segs: segs}, err
+	case c == '$':
+		return nil, p.errorf("filters cannot refer to the root node in a streaming query")
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		return literal{s}, err
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c >= 'a' && c <= 'z':
+		start := p.i
+		for c := p.peek(); c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_'; c = p.peek() {
+			p.i++
+		}
+
+		name := p.s[start:p.i]
+		if p.peek() == '(' {
+			return p.function(start, name)
+		}
+		switch name {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+		p.i = start
+		return nil, p.errorf("unexpected name %q", name)
+	}
+	return nil, p.errorf("expected filter expression")
+}
+
+// number parses a number literal
+func (p *pathParser) number() (interface{}, error) {
+	start := p.i
+	digits := func() int {
+		n := 0
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.i++
+			n++
+		}
+		return n
+	}
+
+	p.consume("-")
+	intStart := p.i
+	n := digits()
+	valid := n == 1 || (n > 1 && p.s[intStart] != '0')
+	if p.consume(".") {
+		valid = valid && digits() > 0
+	}
+	if p.consume("e") || p.consume("E") {
+		if !p.consume("-") {
+			p.consume("+")
+		}
+		valid = valid && digits() > 0
+	}
+
+	f, err := strconv.ParseFloat(p.s[start:p.i], 64)
+	if !valid || err != nil {
+		text := p.s[start:p.i]
+		p.i = start
+		return nil, p.errorf("invalid number %q", text)
+	}
+	return literal{f}, nil
+}
+
+// function parses the arguments of a function call
+func (p *pathParser) function(start int, name string) (interface{}, error) {
+	def, ok := functions[name]
+	if !ok {
+		p.i = start
+		return nil, p.errorf("unknown function %s", name)
+	}
+	f := &funcExpr{name: name, result: def.result}
+
+	p.i++ // '('
+	for i, param := range def.params {
+		p.blank()
+		if i > 0 {
+			if !p.consume(",") {
+				return nil, p.errorf("%s takes %d arguments", name, len(def.params))
+			}
+			p.blank()
+		}
+
+		argStart := p.i
+		var arg interface{}
+		var err error
+		if param == logicalType {
+			arg, err = p.logicalOr()
+		} else {
+			arg, err = p.operand()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch param {
+		case valueType:
+			if arg, ok = comparable(arg); !ok {
+				p.i = argStart
+				return nil, p.errorf("argument %d of %s must be a value", i+1, name)
+			}
+		case nodesType:
+			if _, ok = arg.(*filterQuery); !ok {
+				p.i = argStart
+				return nil, p.errorf("argument %d of %s must be a query", i+1, name)
+			}
+		}
+		f.args = append(f.args, arg)
+	}
+
+	p.blank()
+	if !p.consume(")") {
+		return nil, p.errorf("%s takes %d arguments", name, len(def.params))
+	}
+
+	if l, ok := f.args[len(f.args)-1].(literal); ok && def.result == logicalType {
+		if pattern, ok := l.v.(string); ok {
+			var err error
+			f.re, err = compileIRegexp(pattern, name == "match")
+			f.bad = err != nil
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,521 @@
+package sjson
+
+import (
+	"bytes"
+	"io"
+	"iter"
+	"slices"
+)
+
+// A Match is a value selected by a JSONPath query
+type Match struct {
+	// Path is the normalized path of the value, such as $['items'][0]
+	Path string
+
+	Value Token
+}
+
+// Query evaluates the JSONPath query expr against the first value read
+// from r. See JSONPath.Matches.
+func Query(r io.Reader, expr string) iter.Seq2[Match, error] {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		return func(yield func(Match, error) bool) {
+			yield(Match{}, err)
+		}
+	}
+	return p.Matches(NewDecoder(r))
+}
+
+// Matches evaluates the query against the next value of dec and
+// iterates over the selected values in the order of RFC 9535. Parts of
+// the input that cannot match are skipped without being decoded.
+//
+// The children a segment selects in a node come in the order of its
+// selectors: $.a[1,0] yields the second element of a before the first,
+// $.a[0,0] yields the first element twice and $.a[::-1] yields the
+// elements in reverse order. A descendant segment selects in a node
+// before it selects in the children of the node, so $..[*] on [[1],2]
+// yields $[0], $[1] and then $[0][0].
+//
+// Values are yielded as they are read wherever that order allows, and
+// objects and arrays are yielded unread. Other values are read ahead
+// and kept until their turn: those selected ahead of their selector's
+// turn, the children of a node whose descendants come after the node's
+// later children, the elements a negative index or step may select
+// until the end of the array is found, and each child a filter is
+// applied to. The bytes kept count against MaxBufferedBytes. The
+// iteration ends at the first error, which is yielded with an empty
+// Match. Breaking out of the loop leaves the rest of the value unread.
+func (p *JSONPath) Matches(dec *Decoder) iter.Seq2[Match, error] {
+	return func(yield func(Match, error) bool) {
+		tok, err := nextValue(dec)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			yield(Match{}, err)
+			return
+		}
+
+		m := &matcher{segs: p.segs, opts: dec.r.opts, yield: yield}
+		if m.visit(tok, 0, nil, false) {
+			if err := discard(tok); err != nil {
+				yield(Match{}, err)
+			}
+		}
+	}
+}
+
+// matcher evaluates a query over the token stream
+type matcher struct {
+	segs  []segment
+	opts  DecoderOptions
+	yield func(Match, error) bool
+
+	// held counts the bytes of the children kept for later
+	held int64
+}
+
+// A turn applies segment k to the children of a node: each of its
+// selectors in turn, then for a descendant segment, the segment itself
+// to each child. These are the slots of the turn, and children selected
+// ahead of their slot's turn are kept until then.
+type turn struct {
+	seg    *segment
+	k      int
+	length int // of the array, -1 while unknown
+	slot   int // whose turn it is
+
+	// picked tells which selectors have selected a child, and picks
+	// holds the children kept for each slot. They are nil when the
+	// segment has a single selector that selects in document order.
+	picked []bool
+	picks  [][]*kept
+}
+
+// kept is a child read ahead: a copy of a string, number or literal
+// name, or an object or array and its raw bytes
+type kept struct {
+	tok   Token
+	raw   []byte
+	value interface{}
+	known bool
+}
+
+// visit applies the segments from k on to tok, whose decoded value is
+// given if known. It returns false once the iteration has stopped.
+func (m *matcher) visit(tok Token, k int, value interface{}, known bool) bool {
+	if k == len(m.segs) {
+		return m.yield(Match{Path: pathOf(tok), Value: tok}, nil)
+	}
+	ct, ok := tok.(ComplexToken)
+	if !ok {
+		return true
+	}
+
+	array := tok.Type() == ArrayType
+	length := -1
+	if a, ok := value.([]interface{}); ok && known {
+		length = len(a)
+	}
+
+	t := newTurn(&m.segs[k], k, length)
+	m.narrow(tok, t)
+	filter := t.seg.hasFilter()
+	if !m.advance(t, array, -1, false) {
+		return false
+	}
+
+	for i := 0; ; i++ {
+		child, err := nextValue(ct)
+		if err != nil {
+			return m.fail(err)
+		}
+		if child.Type() == EndType {
+			if array {
+				t.length = i
+			}
+			return m.advance(t, array, -1, true)
+		}
+
+		node := metaOf(child).elem
+		if mt, ok := child.(*MemberToken); ok {
+			child = mt.Value
+		}
+
+		var cv interface{}
+		var cknown bool
+		if known {
+			cv, cknown = childValue(value, &node)
+		}
+
+		var kc *kept
+		if filter && !cknown {
+			if child, kc, cv, err = m.materialize(child); err != nil {
+				return m.fail(err)
+			}
+			cknown = true
+		}
+
+		if !m.read(t, child, &node, kc, cv, cknown) {
+			return false
+		}
+
+		last := -1
+		if array {
+			last = node.index
+		}
+		if !m.advance(t, array, last, false) {
+			return false
+		}
+	}
+}
+
+func (m *matcher) fail(err error) bool {
+	m.yield(Match{}, err)
+	return false
+}
+
+func newTurn(seg *segment, k, length int) *turn {
+	t := &turn{seg: seg, k: k, length: length}
+	if len(seg.sels) > 1 || seg.descendant || !t.inOrder(0) {
+		t.picked = make([]bool, len(seg.sels))
+		t.picks = make([][]*kept, len(seg.sels)+1)
+	}
+	return t
+}
+
+// inOrder tells whether slot j selects children in document order, so
+// that they can be visited as they are read when it is its turn
+func (t *turn) inOrder(j int) bool {
+	if j == len(t.seg.sels) {
+		return true
+	}
+	sel := &t.seg.sels[j]
+	return !sel.reversed() && !(sel.needsLength() && t.length < 0)
+}
+
+// candidate tells whether selector j may select the child at node. All
+// elements are candidates while the length they depend on is unknown.
+func (t *turn) candidate(j int, node *pathNode, value interface{}) bool {
+	sel := &t.seg.sels[j]
+	if sel.needsLength() && t.length < 0 {
+		return node.kind == indexNode
+	}
+	return sel.selects(node, t.length, value)
+}
+
+// next returns the segment applied to the children selected by slot j
+func (t *turn) next(j int) int {
+	if j == len(t.seg.sels) {
+		return t.k
+	}
+	return t.k + 1
+}
+
+// read applies the turn to the child at node and reads it whole. The
+// child is visited as it is read for the slot whose turn it is, and
+// kept for the others, unless kc already keeps it.
+func (m *matcher) read(t *turn, child Token, node *pathNode, kc *kept, value interface{}, known bool) bool {
+	_, complex := child.(ComplexToken)
+	descends := t.seg.descendant && complex
+
+	live, later := -1, false
+	for j := range t.seg.sels {
+		if !t.candidate(j, node, value) {
+			continue
+		}
+		if t.picked != nil {
+			t.picked[j] = true
+		}
+		if j == t.slot && t.inOrder(j) {
+			live = j
+		} else {
+			later = true
+		}
+	}
+	if descends {
+		if t.slot == len(t.seg.sels) {
+			live = t.slot
+		} else {
+			later = true
+		}
+	}
+
+	if later && kc == nil {
+		var ok bool
+		if kc, ok = m.keep(t, child, live, value, known); !ok {
+			return false
+		}
+	} else {
+		if live >= 0 && !m.visit(child, t.next(live), value, known) {
+			return false
+		}
+		if err := discard(child); err != nil {
+			return m.fail(err)
+		}
+	}
+	if !later {
+		return true
+	}
+
+	for j := range t.seg.sels {
+		if j != live && t.candidate(j, node, value) {
+			if err := m.add(t, j, kc); err != nil {
+				return m.fail(err)
+			}
+		}
+	}
+	if descends && live != len(t.seg.sels) {
+		if err := m.add(t, len(t.seg.sels), kc); err != nil {
+			return m.fail(err)
+		}
+	}
+	return true
+}
+
+// keep reads child whole, visiting it for slot live if not negative,
+// and returns a copy of it. It returns false once the iteration has
+// stopped.
+func (m *matcher) keep(t *turn, child Token, live int, value interface{}, known bool) (*kept, bool) {
+	kc := &kept{tok: detach(child), value: value, known: known}
+	if _, ok := child.(ComplexToken); !ok {
+		return kc, live < 0 || m.visit(child, t.next(live), value, known)
+	}
+
+	// the bytes read for the live slot are kept for the others
+	r := readerOf(child)
+	start := r.mark()
+	ok := live < 0 || m.visit(child, t.next(live), value, known)
+	if ok {
+		if err := discard(child); err != nil {
+			ok = m.fail(err)
+		}
+	}
+	d := r.release(start)
+
+	open := byte('{')
+	if child.Type() == ArrayType {
+		open = '['
+	}
+	kc.raw = append(append(make([]byte, 0, len(d)+1), open), d...)
+	return kc, ok
+}
+
+// add keeps kc for slot j, within MaxBufferedBytes. Of the elements
+// that a selector may only select near the end of an array, no more
+// than it may select are kept.
+func (m *matcher) add(t *turn, j int, kc *kept) error {
+	picks := append(t.picks[j], kc)
+	m.held += kc.size()
+
+	if j < len(t.seg.sels) && t.length < 0 {
+		if n := t.seg.sels[j].tail(); n >= 0 && len(picks) > n {
+			m.held -= picks[0].size()
+			picks[0] = nil
+			picks = picks[1:]
+		}
+	}
+	t.picks[j] = picks
+
+	if max := m.opts.MaxBufferedBytes; max > 0 && m.held > max {
+		ti := metaOf(kc.tok)
+		return &LimitExceededError{Limit: "MaxBufferedBytes", Max: max, Position: ti.pos, Path: ti.elem.pointer()}
+	}
+	return nil
+}
+
+// size returns the number of bytes kept
+func (kc *kept) size() int64 {
+	if kc.raw != nil {
+		return int64(len(kc.raw))
+	}
+	return int64(len(textOf(kc.tok)))
+}
+
+// advance passes the turn on from the selectors that can select no more
+// children of a node after the one at index last, or after all of them
+// at the end, visiting the children kept for the next slots. Slots that
+// do not select in document order wait for the end.
+func (m *matcher) advance(t *turn, array bool, last int, end bool) bool {
+	if t.picked == nil {
+		return true
+	}
+
+	sels := t.seg.sels
+	for {
+		if !end && !t.inOrder(t.slot) {
+			return true
+		}
+		if !m.flush(t, t.slot) {
+			return false
+		}
+		if t.slot == len(sels) {
+			return true
+		}
+
+		sel := &sels[t.slot]
+		done := end || t.picked[t.slot] && sel.kind == nameSelector || sel.done(array, last, t.length)
+		if !done {
+			return true
+		}
+		t.slot++
+	}
+}
+
+// flush visits the children kept for slot j. For a slot that depends
+// on the length or does not select in document order, they are
+// selected again now that the length is known, and put in order.
+func (m *matcher) flush(t *turn, j int) bool {
+	picks := t.picks[j]
+	t.picks[j] = nil
+	for _, kc := range picks {
+		m.held -= kc.size()
+	}
+
+	if j < len(t.seg.sels) && (t.seg.sels[j].needsLength() || !t.inOrder(j)) {
+		sel := &t.seg.sels[j]
+		n := 0
+		for _, kc := range picks {
+			node := metaOf(kc.tok).elem
+			if sel.selects(&node, t.length, kc.value) {
+				picks[n] = kc
+				n++
+			}
+		}
+		picks = picks[:n]
+		if sel.reversed() {
+			slices.Reverse(picks)
+		}
+	}
+
+	for _, kc := range picks {
+		tok, err := m.token(kc)
+		if err != nil {
+			return m.fail(err)
+		}
+		if !m.visit(tok, t.next(j), kc.value, kc.known) {
+			return false
+		}
+		if err := discard(tok); err != nil {
+			return m.fail(err)
+		}
+	}
+	return true
+}
+
+// narrow lets the decoder skip the members or elements of tok that the
+// turn cannot select, when they can be told by their key or index alone
+func (m *matcher) narrow(tok Token, t *turn) {
+	if t.seg.descendant {
+		return
+	}
+	for i := range t.seg.sels {
+		sel := &t.seg.sels[i]
+		if sel.kind == wildcardSelector || sel.kind == filterSelector || sel.needsLength() && t.length < 0 {
+			return
+		}
+	}
+
+	want := func(n *pathNode) bool {
+		return t.seg.selects(n, t.length, nil)
+	}
+
+	switch tok := tok.(type) {
+	case objectToken:
+		tok.want = want
+	case arrayToken:
+		tok.want = want
+	}
+}
+
+// materialize reads the child whole and decodes it into an interface{}
+// for filters. An object or array is kept, and returned to be read
+// again from its bytes.
+func (m *matcher) materialize(child Token) (Token, *kept, interface{}, error) {
+	ct, ok := child.(ComplexToken)
+	if !ok {
+		v, err := (&valueDecoder{}).generic(child)
+		return child, nil, v, err
+	}
+
+	kc := &kept{tok: detach(child)}
+	raw, err := ct.Raw()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	kc.raw = raw
+
+	tok, err := m.token(kc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if kc.value, err = (&valueDecoder{}).generic(tok); err != nil {
+		return nil, nil, nil, err
+	}
+	kc.known = true
+
+	tok, err = m.token(kc)
+	return tok, kc, kc.value, err
+}
+
+// childValue returns the value of the child at n of the decoded value v
+func childValue(v interface{}, n *pathNode) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c, ok := v[n.key]
+		return c, ok
+	case []interface{}:
+		if n.index < len(v) {
+			return v[n.index], true
+		}
+	}
+	return nil, false
+}
+
+// readerOf returns the reader of the object or array tok
+func readerOf(tok Token) *reader {
+	switch t := tok.(type) {
+	case objectToken:
+		return t.r
+	case arrayToken:
+		return t.r
+	}
+	return nil
+}
+
+// token returns the kept child, decoding the raw bytes of an object or
+// array again into a token with the same position and path
+func (m *matcher) token(kc *kept) (Token, error) {
+	if kc.raw == nil {
+		return kc.tok, nil
+	}
+
+	opts := m.opts
+	opts.Mode = Concatenated
+	opts.OnDroppedRecord = nil
+
+	dec := NewDecoderWithOptions(bytes.NewReader(kc.raw), opts)
+	ti := metaOf(kc.tok)
+	dec.r.setOrigin(ti.pos)
+	dec.parent = &ti.elem
+
+	// the replayed value is as deeply nested as the original, for
+	// MaxDepth and for skipping
+	switch t := kc.tok.(type) {
+	case objectToken:
+		dec.depth = t.depth - 1
+	case arrayToken:
+		dec.depth = t.depth - 1
+	}
+	dec.r.level = dec.depth
+
+	return dec.Next()
+}
+
+// pathOf returns the normalized path of tok
+func pathOf(tok Token) string {
+	n := metaOf(tok).elem
+	return n.normalized()
+}
@@ -0,0 +1,279 @@
+package sjson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const store = `{"store": {"book": [` +
+	`{"category": "reference", "author": "Nigel Rees", "title": "Sayings", "price": 8.95}, ` +
+	`{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword", "price": 12.99}, ` +
+	`{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99}` +
+	`], "bicycle": {"color": "red", "price": 399}}}`
+
+// queryAll returns the path and text of each match, objects and arrays
+// being given by their raw bytes
+func queryAll(doc, expr string) ([]string, error) {
+	var out []string
+	for m, err := range Query(strings.NewReader(doc), expr) {
+		if err != nil {
+			return out, err
+		}
+
+		v := toText(m.Value)
+		if ct, ok := m.Value.(ComplexToken); ok {
+			raw, err := ct.Raw()
+			if err != nil {
+				return out, err
+			}
+			v = string(raw)
+		}
+		out = append(out, m.Path+" "+v)
+	}
+	return out, nil
+}
+
+func TestJSONPath(t *testing.T) {
+
+	Convey("Invalid queries should not compile", t, func() {
+		for _, expr := range []string{
+			"", "a", "$.", "$ ", "$[", "$['a'", "$[01]", "$[-0]", "$[1 2]", "$.a.'b'",
+			"$[?@.a == @.*]", "$[?length(@.*) == 1]", "$[?foo(@)]", "$[?count(@.a, 1)]",
+			"$[?1]", "$[?@.a == 01]", "$[?match(@.a)]", "$[?length(@.a)]",
+		} {
+			_, err := CompileJSONPath(expr)
+			So(errors.Is(err, ErrInvalidJSONPath), ShouldBeTrue)
+		}
+	})
+
+	Convey("Filters on the root node should be rejected", t, func() {
+		_, err := CompileJSONPath("$.a[?@.b == $.c]")
+		So(errors.Is(err, ErrInvalidJSONPath), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "root node")
+	})
+
+	Convey("Child, wildcard and descendant segments should select in document order", t, func() {
+		got, err := queryAll(store, "$.store.book[*].author")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{
+			"$['store']['book'][0]['author'] Nigel Rees",
+			"$['store']['book'][1]['author'] Evelyn Waugh",
+			"$['store']['book'][2]['author'] Herman Melville",
+		})
+
+		got, err = queryAll(store, "$..price")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{
+			"$['store']['book'][0]['price'] 8.95",
+			"$['store']['book'][1]['price'] 12.99",
+			"$['store']['book'][2]['price'] 8.99",
+			"$['store']['bicycle']['price'] 399",
+		})
+
+		got, err = queryAll(store, `$["store"].*`)
+		So(err, ShouldBeNil)
+		So(got, ShouldHaveLength, 2)
+		So(got[1], ShouldEqual, `$['store']['bicycle'] {"color": "red", "price": 399}`)
+
+		got, err = queryAll(store, "$")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$ " + store})
+	})
+
+	Convey("Selected values should still be searched for other matches", t, func() {
+		got, err := queryAll(store, "$..*")
+		So(err, ShouldBeNil)
+		So(got, ShouldHaveLength, 21)
+		So(got[0], ShouldStartWith, "$['store'] {")
+		So(got[1], ShouldStartWith, "$['store']['book'] [")
+		So(got[2], ShouldStartWith, "$['store']['bicycle'] {")
+		So(got[3], ShouldStartWith, "$['store']['book'][0] {")
+		So(got[6], ShouldEqual, "$['store']['book'][0]['category'] reference")
+	})
+
+	Convey("Descendant segments should select in a node before its descendants", t, func() {
+		got, err := queryAll(`[[1], 2]`, "$..[*]")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$[0] [1]", "$[1] 2", "$[0][0] 1"})
+
+		got, err = queryAll(`{"a": {"b": 1}, "b": 2}`, "$..b")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$['b'] 2", "$['a']['b'] 1"})
+
+		got, err = queryAll(`{"a": [{"b": [{"b": 1}]}], "b": 2}`, "$..b[*]")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{`$['a'][0]['b'][0] {"b": 1}`})
+	})
+
+	Convey("Values kept for later should count against MaxBufferedBytes", t, func() {
+		in := `{"a": [1, 2, 3, 4, 5, 6, 7, 8], "b": 1}`
+		p := MustCompileJSONPath("$..b")
+
+		dec := NewDecoderWithOptions(strings.NewReader(in), DecoderOptions{MaxBufferedBytes: 16})
+		var err error
+		for _, err = range p.Matches(dec) {
+		}
+		So(errors.Is(err, ErrLimitExceeded), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "MaxBufferedBytes")
+
+		dec = NewDecoderWithOptions(strings.NewReader(in), DecoderOptions{MaxBufferedBytes: 64})
+		var n int
+		for _, err := range p.Matches(dec) {
+			So(err, ShouldBeNil)
+			n++
+		}
+		So(n, ShouldEqual, 1)
+	})
+
+	Convey("Selected values should be searched whether the loop body reads them or not", t, func() {
+		in := `{"a": {"b": [1, {"b": 2}]}, "c": {"b": 3}}`
+		for _, read := range []func(Token){
+			func(Token) {},
+			func(tok Token) { tok.(ComplexToken).Next() },
+			func(tok Token) { tok.(ComplexToken).Skip() },
+		} {
+			var got []string
+			for m, err := range Query(strings.NewReader(in), "$..b") {
+				So(err, ShouldBeNil)
+				got = append(got, m.Path)
+				if _, ok := m.Value.(ComplexToken); ok {
+					read(m.Value)
+				}
+			}
+			So(got, ShouldResemble, []string{"$['a']['b']", "$['a']['b'][1]['b']", "$['c']['b']"})
+		}
+
+		got, err := queryAll(`{"a": [1, [1, 2], {"b": 1}]}`, "$..[?@ == 1]")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$['a'][0] 1", "$['a'][1][0] 1", "$['a'][2]['b'] 1"})
+	})
+
+	Convey("Indices, slices and unions should select elements in the order of the selectors", t, func() {
+		titles := func(expr string) []string {
+			got, err := queryAll(store, expr)
+			So(err, ShouldBeNil)
+			for i, s := range got {
+				got[i] = s[strings.LastIndexByte(s, ']')+2:]
+			}
+			return got
+		}
+
+		So(titles("$..book[2].title"), ShouldResemble, []string{"Moby Dick"})
+		So(titles("$..book[-1].title"), ShouldResemble, []string{"Moby Dick"})
+		So(titles("$..book[1,0,1].title"), ShouldResemble, []string{"Sword", "Sayings", "Sword"})
+		So(titles("$..book[0,0].title"), ShouldResemble, []string{"Sayings", "Sayings"})
+		So(titles("$..book[2,*].title"), ShouldResemble, []string{"Moby Dick", "Sayings", "Sword", "Moby Dick"})
+		So(titles("$..book[-1,0:2].title"), ShouldResemble, []string{"Moby Dick", "Sayings", "Sword"})
+		So(titles("$..book[:2].title"), ShouldResemble, []string{"Sayings", "Sword"})
+		So(titles("$..book[1:].title"), ShouldResemble, []string{"Sword", "Moby Dick"})
+		So(titles("$..book[::2].title"), ShouldResemble, []string{"Sayings", "Moby Dick"})
+		So(titles("$..book[-2:].title"), ShouldResemble, []string{"Sword", "Moby Dick"})
+		So(titles("$..book[::-1].title"), ShouldResemble, []string{"Moby Dick", "Sword", "Sayings"})
+		So(titles("$..book[1::-1].title"), ShouldResemble, []string{"Sword", "Sayings"})
+		So(titles("$..book[5].title"), ShouldBeEmpty)
+		So(titles("$..book[0:0].title"), ShouldBeEmpty)
+		So(titles("$..book[::0].title"), ShouldBeEmpty)
+
+		got, err := queryAll(store, "$.store.bicycle['price','color']")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$['store']['bicycle']['price'] 399", "$['store']['bicycle']['color'] red"})
+
+		got, err = queryAll(`{"a": [[1, 2], [3]]}`, "$.a[1,0][1,0]")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$['a'][1][0] 3", "$['a'][0][1] 2", "$['a'][0][0] 1"})
+
+		got, err = queryAll(`{"a": [[1, 2], [3, 4]]}`, "$.a[1,0][1,0]")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$['a'][1][1] 4", "$['a'][1][0] 3", "$['a'][0][1] 2", "$['a'][0][0] 1"})
+	})
+
+	Convey("Negative indices and slices should select from the end of the array", t, func() {
+		values := func(expr string) []string {
+			got, err := queryAll(`{"c": [1, 2, 3, 4, 5]}`, expr)
+			So(err, ShouldBeNil)
+			for i, s := range got {
+				got[i] = s[strings.LastIndexByte(s, ' ')+1:]
+			}
+			return got
+		}
+
+		So(values("$.c[-2]"), ShouldResemble, []string{"4"})
+		So(values("$.c[-3]"), ShouldResemble, []string{"3"})
+		So(values("$.c[-5]"), ShouldResemble, []string{"1"})
+		So(values("$.c[-6]"), ShouldBeEmpty)
+		So(values("$.c[-3:-1]"), ShouldResemble, []string{"3", "4"})
+		So(values("$.c[-6:-4]"), ShouldResemble, []string{"1"})
+		So(values("$.c[-10:2]"), ShouldResemble, []string{"1", "2"})
+		So(values("$['c'][-2,1]"), ShouldResemble, []string{"4", "2"})
+		So(values("$['c'][1,-2]"), ShouldResemble, []string{"2", "4"})
+		So(values("$..[-2]"), ShouldResemble, []string{"4"})
+	})
+
+	Convey("Filters should select by the value of each child", t, func() {
+		titles := func(expr string) []string {
+			got, err := queryAll(store, expr)
+			So(err, ShouldBeNil)
+			for i, s := range got {
+				got[i] = s[strings.LastIndexByte(s, ']')+2:]
+			}
+			return got
+		}
+
+		So(titles("$..book[?@.isbn].title"), ShouldResemble, []string{"Moby Dick"})
+		So(titles("$..book[?!@.isbn].title"), ShouldResemble, []string{"Sayings", "Sword"})
+		So(titles("$..book[?@.price < 10].title"), ShouldResemble, []string{"Sayings", "Moby Dick"})
+		So(titles("$..book[?@.price >= 8.99 && @.category != 'reference'].title"), ShouldResemble, []string{"Sword", "Moby Dick"})
+		So(titles("$..book[?match(@.author, 'H.*') || search(@.title, 'ord')].title"), ShouldResemble, []string{"Sword", "Moby Dick"})
+		So(titles("$..book[?length(@.title) == 5].title"), ShouldResemble, []string{"Sword"})
+		So(titles("$..book[?value(@..isbn) == '0-553-21311-3'].title"), ShouldResemble, []string{"Moby Dick"})
+		So(titles(`$..book[?(@.price > 9 || @.category == "reference") && @.title != 'Sword'].title`), ShouldResemble, []string{"Sayings"})
+
+		got, err := queryAll(store, "$..[?@.price > 100]")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{`$['store']['bicycle'] {"color": "red", "price": 399}`})
+
+		got, err = queryAll(store, "$.store[?count(@.*) == 2].color")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"$['store']['bicycle']['color'] red"})
+	})
+
+	Convey("Matches should keep their position in the input", t, func() {
+		var n int
+		for m, err := range Query(strings.NewReader(store), "$..book[-1]") {
+			So(err, ShouldBeNil)
+			So(m.Value.Position().Offset, ShouldEqual, strings.Index(store, `{"category": "fiction", "author": "Herman`))
+			n++
+		}
+		So(n, ShouldEqual, 1)
+	})
+
+	Convey("Normalized paths should escape member names", t, func() {
+		got, err := queryAll(`{"a'b\n\\\u0001": 1, "c": [true]}`, "$..*")
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{`$['a\'b\n\\\u0001'] 1`, "$['c'] [true]", "$['c'][0] true"})
+	})
+
+	Convey("Breaking out of the loop should stop reading", t, func() {
+		in := `[{"id": 1}, {"id": 2}, {"id": 3}]`
+		dec := NewDecoder(strings.NewReader(in))
+		for m, err := range MustCompileJSONPath("$..id").Matches(dec) {
+			So(err, ShouldBeNil)
+			So(m.Path, ShouldEqual, "$[0]['id']")
+			break
+		}
+		So(dec.InputOffset(), ShouldEqual, strings.Index(in, "}"))
+	})
+
+	Convey("Errors in the input should end the iteration", t, func() {
+		got, err := queryAll(`{"a": [1, 2}, "b": 3}`, "$.a[*]")
+		So(got, ShouldResemble, []string{"$['a'][0] 1", "$['a'][1] 2"})
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+
+		got, err = queryAll(`{"a": [{"b": 1}, {"b": ]}`, "$.a[?@.b]")
+		So(got, ShouldResemble, []string{"$['a'][0] {\"b\": 1}"})
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+	})
+}
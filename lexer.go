@@ -0,0 +1,342 @@
+package sjson
+
+import (
+	"io"
+)
+
+// The lexer works directly on the bytes buffered by the reader. Values
+// are returned as parts of the buffer, which stay valid until the next
+// read; only strings with escapes are copied, unescaped.
+
+// window returns the buffered bytes, reading more if there are none
+func (r *reader) window() ([]byte, error) {
+	if r.off == len(r.buf) {
+		if err := r.fill(); err != nil {
+			return nil, err
+		}
+	}
+	return r.buf[r.off:], nil
+}
+
+// extend returns a window of more than n bytes, or a shorter one and
+// io.EOF if the input ends first
+func (r *reader) extend(n int) ([]byte, error) {
+	for len(r.buf)-r.off <= n {
+		if err := r.fill(); err != nil {
+			return r.buf[r.off:], err
+		}
+	}
+	return r.buf[r.off:], nil
+}
+
+// skipSpace consumes whitespace
+func (r *reader) skipSpace() error {
+	if r.opts.Syntax == JSON5 {
+		return r.skipSpace5()
+	}
+
+	for {
+		d, err := r.window()
+		if err != nil {
+			return err
+		}
+
+		i := 0
+		for i < len(d) && (d[i] == ' ' || d[i] == '\t' || d[i] == '\n' || d[i] == '\r') {
+			i++
+		}
+		r.off += i
+
+		if i < len(d) {
+			return nil
+		}
+	}
+}
+
+// number grammar states
+const (
+	numSign = iota
+	numInt
+	numZero
+	numDigits
+	numFracFirst
+	numFrac
+	numExpSign
+	numExpFirst
+	numExp
+)
+
+// scanNumber consumes a number following the JSON number grammar and
+// returns its text, and whether it has a fraction or exponent
+func (r *reader) scanNumber() ([]byte, bool, error) {
+	if d, _ := r.window(); len(d) > 0 {
+		if n, isFloat, ok := plainNumber(d); ok {
+			if max := r.opts.MaxNumberLength; max == 0 || n <= max {
+				r.Discard(n)
+				return d[:n], isFloat, nil
+			}
+		}
+	}
+
+	var isFloat bool
+
+	state := numSign
+	i := 0
+
+	d, err := r.window()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		if i == len(d) {
+			d, err = r.extend(i)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, false, err
+			}
+		}
+
+		b := d[i]
+		next := -1
+
+		switch state {
+		case numSign:
+			next = numInt
+			if b == '-' {
+				i++
+			}
+			state = next
+			continue
+		case numInt:
+			if b == '0' {
+				next = numZero
+			} else if b >= '1' && b <= '9' {
+				next = numDigits
+			} else {
+				return nil, false, r.numberError("expected digit", d[:i], nil)
+			}
+		case numZero:
+			if b >= '0' && b <= '9' {
+				return nil, false, r.numberError("leading zero", d[:i], d[i:i+1])
+			}
+			fallthrough
+		case numDigits:
+			if b >= '0' && b <= '9' {
+				next = numDigits
+			} else if b == '.' {
+				next = numFracFirst
+			} else if b == 'e' || b == 'E' {
+				next = numExpSign
+			}
+		case numFracFirst:
+			if b < '0' || b > '9' {
+				return nil, false, r.numberError("expected digit after decimal point", d[:i], nil)
+			}
+			next = numFrac
+		case numFrac:
+			if b >= '0' && b <= '9' {
+				next = numFrac
+			} else if b == 'e' || b == 'E' {
+				next = numExpSign
+			}
+		case numExpSign:
+			if b == '+' || b == '-' {
+				next = numExpFirst
+			} else if b >= '0' && b <= '9' {
+				next = numExp
+			} else {
+				return nil, false, r.numberError("expected digit in exponent", d[:i], nil)
+			}
+		case numExpFirst:
+			if b < '0' || b > '9' {
+				return nil, false, r.numberError("expected digit in exponent", d[:i], nil)
+			}
+			next = numExp
+		case numExp:
+			if b >= '0' && b <= '9' {
+				next = numExp
+			}
+		}
+
+		if next == -1 {
+			// a number must not run straight into another number
+			if b == '.' || b == 'e' || b == 'E' || b == '+' || b == '-' || (b >= '0' && b <= '9') {
+				return nil, false, r.numberError("unexpected character", d[:i], d[i:i+1])
+			}
+			break
+		}
+
+		if next == numFracFirst || next == numExpSign {
+			isFloat = true
+		}
+		state = next
+		i++
+
+		if max := r.opts.MaxNumberLength; max > 0 && i > max {
+			return nil, false, r.limitError("MaxNumberLength", int64(max))
+		}
+	}
+
+	switch state {
+	case numSign, numInt:
+		return nil, false, r.numberError("expected digit", d[:i], nil)
+	case numFracFirst:
+		return nil, false, r.numberError("expected digit after decimal point", d[:i], nil)
+	case numExpSign, numExpFirst:
+		return nil, false, r.numberError("expected digit in exponent", d[:i], nil)
+	}
+
+	r.Discard(i)
+	return d[:i], isFloat, nil
+}
+
+// plainNumber returns the length of the valid number d begins with, if
+// it is followed by a delimiter in d. Anything else is left to the
+// grammar of scanNumber, which reports the errors.
+func plainNumber(d []byte) (int, bool, bool) {
+	i := 0
+	if d[0] == '-' {
+		i++
+	}
+
+	switch {
+	case i < len(d) && d[i] == '0':
+		i++
+	case i < len(d) && d[i] >= '1' && d[i] <= '9':
+		i = digits(d, i+1)
+	default:
+		return 0, false, false
+	}
+
+	isFloat := false
+	if i < len(d) && d[i] == '.' {
+		j := digits(d, i+1)
+		if j == i+1 {
+			return 0, false, false
+		}
+		i, isFloat = j, true
+	}
+	if i < len(d) && (d[i] == 'e' || d[i] == 'E') {
+		i++
+		if i < len(d) && (d[i] == '+' || d[i] == '-') {
+			i++
+		}
+		j := digits(d, i)
+		if j == i {
+			return 0, false, false
+		}
+		i, isFloat = j, true
+	}
+
+	if i == len(d) {
+		return 0, false, false
+	}
+	switch d[i] {
+	case '.', 'e', 'E', '+', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return 0, false, false
+	}
+	return i, isFloat, true
+}
+
+// digits returns the index of the first byte of d from i on that is
+// not a digit
+func digits(d []byte, i int) int {
+	for i < len(d) && d[i] >= '0' && d[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// numberError creates a NumberError for a number made of the
+// unconsumed part head and the offending byte extra, positioned after
+// head
+func (r *reader) numberError(msg string, head, extra []byte) *NumberError {
+	pos := r.position()
+	pos.Offset += int64(len(head))
+	pos.Column += len(head)
+
+	num := string(head) + string(extra)
+	return &NumberError{Num: num, Msg: msg, Position: pos}
+}
+
+// scanString consumes a string whose opening quote has already been
+// consumed and returns its unescaped text. Strings without escapes are
+// returned as a part of the buffer. A string longer than
+// MaxStringLength fails with the text read so far.
+func (r *reader) scanString(quote byte) ([]byte, error) {
+	d, err := r.window()
+	for i := 0; err == nil; {
+		for ; i < len(d); i++ {
+			b := d[i]
+			if b == quote {
+				if max := r.opts.MaxStringLength; max > 0 && i > max {
+					return d[:i], r.limitError("MaxStringLength", int64(max))
+				}
+				r.Discard(i + 1)
+				return d[:i], nil
+			}
+			if b == '\\' || b < 0x20 {
+				// let the slow path unescape or report the error
+				return readString(r, quote)
+			}
+		}
+		if max := r.opts.MaxStringLength; max > 0 && i > max {
+			return d[:i], r.limitError("MaxStringLength", int64(max))
+		}
+		d, err = r.extend(i)
+	}
+
+	if err != io.EOF {
+		return nil, err
+	}
+	// let the slow path report the end of the input
+	return readString(r, quote)
+}
+
+// limits of the keys kept by intern
+const (
+	maxInternedKeys   = 1024
+	maxInternedKeyLen = 64
+)
+
+// intern returns the member key as a string, reusing the string of an
+// earlier key with the same bytes. The objects of a document tend to
+// repeat their keys, which are then only allocated once.
+func (r *reader) intern(key []byte) string {
+	if s, ok := r.keys[string(key)]; ok {
+		return s
+	}
+
+	s := string(key)
+	if len(key) <= maxInternedKeyLen && len(r.keys) < maxInternedKeys {
+		if r.keys == nil {
+			r.keys = make(map[string]string)
+		}
+		r.keys[s] = s
+	}
+	return s
+}
+
+// scanLiteral consumes the literal name lit
+func (r *reader) scanLiteral(lit string) error {
+	d, err := r.Peek(len(lit))
+	for i := 0; i < len(d); i++ {
+		if d[i] != lit[i] {
+			r.Discard(i)
+			return r.unexpected(d[i:i+1], "'"+lit[i:i+1]+"' in "+lit)
+		}
+	}
+	if err != nil {
+		r.Discard(len(d))
+		if err == io.EOF {
+			return r.eofError("Unexpected end of input in " + lit)
+		}
+		return err
+	}
+
+	r.Discard(len(lit))
+	return nil
+}
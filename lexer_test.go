@@ -0,0 +1,151 @@
+package sjson
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLexer(t *testing.T) {
+
+	Convey("Values longer than the read buffer should decode whole", t, func() {
+		digits := strings.Repeat("1234567890", 1000)
+		text := strings.Repeat("abcdefghij", 1000)
+		in := `[` + digits + `, -` + digits + `.5e+` + digits + `, "` + text + `", "` + text + `\n"]`
+
+		dec := NewDecoder(strings.NewReader(in))
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		want := []string{digits, "-" + digits + ".5e+" + digits, text, text + "\n"}
+		for _, w := range want {
+			t, err = ct.Next()
+			So(err, ShouldBeNil)
+			So(toText(t), ShouldEqual, w)
+		}
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, EndType)
+	})
+
+	Convey("Long invalid numbers should report the whole number", t, func() {
+		digits := strings.Repeat("9", 5000)
+		dec := NewDecoder(strings.NewReader(digits + `.x`))
+
+		_, err := dec.Next()
+		var ne *NumberError
+		So(errors.As(err, &ne), ShouldBeTrue)
+		So(ne.Num, ShouldEqual, digits+".")
+		So(ne.Position.Offset, ShouldEqual, 5001)
+	})
+
+	Convey("Positions should follow whitespace skipped in bulk", t, func() {
+		dec := NewDecoder(strings.NewReader("{\n  \"a\":\n\t\t[1,\r\n   2]}"))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		t, err = t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+
+		arr := t.(*MemberToken).Value.(ComplexToken)
+		So(t.(*MemberToken).Value.Position(), ShouldResemble, Position{Offset: 11, Line: 3, Column: 3})
+
+		_, err = arr.Next()
+		So(err, ShouldBeNil)
+		t, err = arr.Next()
+		So(err, ShouldBeNil)
+		So(t.Position(), ShouldResemble, Position{Offset: 19, Line: 4, Column: 4})
+	})
+}
+
+// corpus lists the benchmark inputs in testdata, see testdata/README.md
+var corpus = []string{"canada_geometry", "citm_catalog", "golang_source", "string_escaped", "twitter_status"}
+
+// readCorpus returns the benchmark input name
+func readCorpus(b *testing.B, name string) []byte {
+	f, err := os.Open(filepath.Join("testdata", name+".json.gz"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		b.Fatal(err)
+	}
+	in, err := io.ReadAll(r)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return in
+}
+
+// drain reads every token of ct
+func drain(ct ComplexToken) error {
+	for {
+		t, err := ct.Next()
+		if err != nil {
+			return err
+		}
+		if t.Type() == EndType {
+			return nil
+		}
+		if mt, ok := t.(*MemberToken); ok {
+			t = mt.Value
+		}
+		if c, ok := t.(ComplexToken); ok {
+			if err := drain(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	for _, name := range corpus {
+		in := readCorpus(b, name)
+
+		b.Run(name+"/sjson", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(in)))
+			for i := 0; i < b.N; i++ {
+				dec := NewDecoderWithOptions(bytes.NewReader(in), DecoderOptions{ReuseTokens: true})
+				t, err := dec.Next()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := drain(t.(ComplexToken)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(name+"/encoding_json", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(in)))
+			for i := 0; i < b.N; i++ {
+				dec := json.NewDecoder(bytes.NewReader(in))
+				for {
+					_, err := dec.Token()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
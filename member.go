@@ -4,6 +4,8 @@ import "fmt"
 
 // A MemberToken is a token that is a member of an object
 type MemberToken struct {
+	tokenInfo
+
 	Key   string
 	Value Token
 }
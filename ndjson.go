@@ -0,0 +1,210 @@
+package sjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A LineError is an error in one line of newline-delimited JSON
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// An NDJSONOption configures an NDJSONReader
+type NDJSONOption func(*NDJSONReader)
+
+// SkipBadLines makes the NDJSONReader skip lines that are not valid
+// JSON, or that cannot be decoded into the given value, instead of
+// failing. Each skipped line is reported to report as a *LineError.
+func SkipBadLines(report func(err *LineError)) NDJSONOption {
+	return func(nr *NDJSONReader) {
+		nr.report = report
+	}
+}
+
+// NDJSONDecoderOptions sets the options of the decoders reading each
+// line, such as their syntax and limits. Each line holds exactly one
+// value whatever the Mode.
+func NDJSONDecoderOptions(opts DecoderOptions) NDJSONOption {
+	return func(nr *NDJSONReader) {
+		nr.opts = opts
+	}
+}
+
+// An NDJSONReader reads newline-delimited JSON (JSON Lines): one
+// top-level value per line. Blank lines are ignored and lines may end
+// in CRLF. The positions of tokens carry their line number.
+type NDJSONReader struct {
+	r      *bufio.Reader
+	report func(err *LineError)
+	opts   DecoderOptions
+
+	// buf holds the current line, which starts at offset start
+	buf    []byte
+	line   int
+	start  int64
+	offset int64
+
+	// dec is the decoder of the token last returned by Next, whose line
+	// is checked for trailing data before moving on
+	dec *Decoder
+}
+
+// NewNDJSONReader creates a new NDJSONReader from the given reader
+func NewNDJSONReader(r io.Reader, opts ...NDJSONOption) *NDJSONReader {
+	nr := &NDJSONReader{r: bufio.NewReader(r)}
+	for _, opt := range opts {
+		opt(nr)
+	}
+	return nr
+}
+
+// Line returns the line number of the last value read
+func (nr *NDJSONReader) Line() int {
+	return nr.line
+}
+
+// Next returns the top-level token of the next line. What is left of
+// an object or array token is skipped by the next call, and the rest of
+// its line must be blank. When skipping bad lines, each line is checked
+// in full before its token is returned.
+func (nr *NDJSONReader) Next() (Token, error) {
+	if err := nr.endLine(); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := nr.nextLine(); err != nil {
+			return nil, err
+		}
+
+		if nr.report != nil {
+			err := readAll(nr.decoder(), func(Token) error { return nil })
+			if err != io.EOF {
+				nr.report(&LineError{Line: nr.line, Err: err})
+				continue
+			}
+		}
+
+		dec := nr.decoder()
+		tok, err := dec.Next()
+		if err != nil {
+			return nil, &LineError{Line: nr.line, Err: err}
+		}
+		nr.dec = dec
+		return tok, nil
+	}
+}
+
+// endLine checks that only whitespace follows the value of the token
+// last returned by Next on its line
+func (nr *NDJSONReader) endLine() error {
+	dec := nr.dec
+	if dec == nil {
+		return nil
+	}
+	nr.dec = nil
+
+	if err := dec.end(); err != io.EOF {
+		return &LineError{Line: nr.line, Err: err}
+	}
+	return nil
+}
+
+// Decode decodes the value of the next line into v
+func (nr *NDJSONReader) Decode(v interface{}) error {
+	if err := nr.endLine(); err != nil {
+		return err
+	}
+
+	for {
+		if err := nr.nextLine(); err != nil {
+			return err
+		}
+
+		err := nr.decoder().Decode(v)
+		if err == nil {
+			return nil
+		}
+
+		lerr := &LineError{Line: nr.line, Err: err}
+		if nr.report == nil {
+			return lerr
+		}
+		nr.report(lerr)
+	}
+}
+
+// nextLine reads the next line that is not blank
+func (nr *NDJSONReader) nextLine() error {
+	for {
+		line, err := nr.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return err
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		nr.buf = line
+		nr.line++
+		nr.start = nr.offset
+		nr.offset += int64(len(line))
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			return nil
+		}
+	}
+}
+
+// decoder creates a decoder for exactly one value on the current line
+func (nr *NDJSONReader) decoder() *Decoder {
+	opts := nr.opts
+	opts.Mode = Strict
+	dec := NewDecoderWithOptions(bytes.NewReader(nr.buf), opts)
+	dec.r.setOrigin(Position{Offset: nr.start, Line: nr.line, Column: 1})
+	return dec
+}
+
+// An NDJSONWriter writes newline-delimited JSON: one value per line
+type NDJSONWriter struct {
+	enc *Encoder
+}
+
+// NewNDJSONWriter creates a new NDJSONWriter writing to w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: NewEncoder(w)}
+}
+
+// WriteToken writes t as one line. Objects and arrays are copied up to
+// their EndType token.
+func (nw *NDJSONWriter) WriteToken(t Token) error {
+	return nw.enc.Copy(t)
+}
+
+// Encode writes the encoding/json representation of v as one line
+func (nw *NDJSONWriter) Encode(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	t, err := NewDecoder(bytes.NewReader(b)).Next()
+	if err != nil {
+		return err
+	}
+	return nw.enc.Copy(t)
+}
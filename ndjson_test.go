@@ -0,0 +1,145 @@
+package sjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNDJSON(t *testing.T) {
+
+	Convey("The reader should yield one token per line with its line number", t, func() {
+		in := "{\"a\": 1}\r\n\n  \r\n[2, 3]\n\"four\""
+		nr := NewNDJSONReader(strings.NewReader(in))
+
+		t, err := nr.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ObjectType)
+		So(t.Position(), ShouldResemble, Position{Offset: 0, Line: 1, Column: 1})
+		So(drain(t.(ComplexToken)), ShouldBeNil)
+
+		t, err = nr.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ArrayType)
+		So(t.Position(), ShouldResemble, Position{Offset: 15, Line: 4, Column: 1})
+		So(nr.Line(), ShouldEqual, 4)
+		So(drain(t.(ComplexToken)), ShouldBeNil)
+
+		t, err = nr.Next()
+		So(err, ShouldBeNil)
+		So(toText(t), ShouldEqual, "four")
+		So(nr.Line(), ShouldEqual, 5)
+
+		_, err = nr.Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("The reader should fail on a bad line by default", t, func() {
+		nr := NewNDJSONReader(strings.NewReader("1\n2 3\n4\n"))
+
+		var v int
+		So(nr.Decode(&v), ShouldBeNil)
+
+		err := nr.Decode(&v)
+		var le *LineError
+		So(errors.As(err, &le), ShouldBeTrue)
+		So(le.Line, ShouldEqual, 2)
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+	})
+
+	Convey("The reader should fail on trailing data after a partly read value", t, func() {
+		nr := NewNDJSONReader(strings.NewReader("{\"a\": 1} junk\n{\"b\": [2, 3]}\n4\n"))
+
+		t, err := nr.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ObjectType)
+
+		_, err = nr.Next()
+		var le *LineError
+		So(errors.As(err, &le), ShouldBeTrue)
+		So(le.Line, ShouldEqual, 1)
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+
+		// the rest of a value read in part is skipped
+		t, err = nr.Next()
+		So(err, ShouldBeNil)
+		So(nr.Line(), ShouldEqual, 2)
+		_, err = t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+
+		t, err = nr.Next()
+		So(err, ShouldBeNil)
+		So(toText(t), ShouldEqual, "4")
+	})
+
+	Convey("The reader should decode each line with the given options", t, func() {
+		nr := NewNDJSONReader(strings.NewReader("{a: 1} // one\n[2,]\n"), NDJSONDecoderOptions(DecoderOptions{Syntax: JSON5, Mode: Concatenated}))
+
+		var v struct{ A int }
+		So(nr.Decode(&v), ShouldBeNil)
+		So(v.A, ShouldEqual, 1)
+
+		var w []int
+		So(nr.Decode(&w), ShouldBeNil)
+		So(w, ShouldResemble, []int{2})
+
+		nr = NewNDJSONReader(strings.NewReader("1 2\n"), NDJSONDecoderOptions(DecoderOptions{Mode: Concatenated}))
+		So(errors.Is(nr.Decode(&v), ErrSyntax), ShouldBeTrue)
+	})
+
+	Convey("The reader should skip and report bad lines when asked", t, func() {
+		in := "{\"n\": 1}\n{\"n\": \n{\"n\": \"x\"}\n{\"n\": 4} {}\n{\"n\": 5}\n"
+
+		var bad []int
+		report := func(err *LineError) {
+			bad = append(bad, err.Line)
+		}
+
+		nr := NewNDJSONReader(strings.NewReader(in), SkipBadLines(report))
+		var got []int
+		for {
+			var v struct{ N int }
+			err := nr.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			So(err, ShouldBeNil)
+			got = append(got, v.N)
+		}
+		So(got, ShouldResemble, []int{1, 5})
+		So(bad, ShouldResemble, []int{2, 3, 4})
+
+		bad = nil
+		nr = NewNDJSONReader(strings.NewReader(in), SkipBadLines(report))
+		var lines []int
+		for {
+			t, err := nr.Next()
+			if err == io.EOF {
+				break
+			}
+			So(err, ShouldBeNil)
+			So(drain(t.(ComplexToken)), ShouldBeNil)
+			lines = append(lines, nr.Line())
+		}
+		So(lines, ShouldResemble, []int{1, 3, 5})
+		So(bad, ShouldResemble, []int{2, 4})
+	})
+
+	Convey("The writer should write one value per line", t, func() {
+		var buf bytes.Buffer
+		nw := NewNDJSONWriter(&buf)
+
+		So(nw.Encode(map[string]interface{}{"a": []int{1, 2}}), ShouldBeNil)
+		So(nw.Encode("x\ny"), ShouldBeNil)
+
+		t, err := NewDecoder(strings.NewReader(` { "b" : [ true , null ] } `)).Next()
+		So(err, ShouldBeNil)
+		So(nw.WriteToken(t), ShouldBeNil)
+
+		So(buf.String(), ShouldEqual, "{\"a\":[1,2]}\n\"x\\ny\"\n{\"b\":[true,null]}\n")
+	})
+}
@@ -1,15 +1,26 @@
 package sjson
 
-import "errors"
+import "reflect"
 
-var nullToken _nullToken
-
-type _nullToken struct{}
+type nullToken struct {
+	*scalar
+}
 
-func (n _nullToken) Type() Type {
+func (n nullToken) Type() Type {
 	return NullType
 }
 
-func (n _nullToken) Unmarshal(i interface{}) error {
-	return errors.New("Unable to unmarshal a null JSON value")
+func (n nullToken) Unmarshal(i interface{}) error {
+	v, err := n.unmarshalTarget(i, "null")
+	if err != nil {
+		return err
+	}
+	if ok, err := unmarshaler(n, v); ok {
+		return err
+	}
+	return n.typeError("null", reflect.TypeOf(i))
+}
+
+func (n nullToken) String() string {
+	return "null"
 }
@@ -1,49 +1,69 @@
 package sjson
 
-import (
-	"fmt"
-	"reflect"
-	"strconv"
-)
+import "fmt"
 
-type numberToken string
+// A NumberError is returned when a number does not follow the
+// JSON number grammar
+type NumberError struct {
+	Num      string
+	Msg      string
+	Position Position
+	Path     string
+}
+
+func (e *NumberError) Error() string {
+	return fmt.Sprintf("Malformed number %s: %s at %s%s", e.Num, e.Msg, e.Position, pathSuffix(e.Path))
+}
+
+// Unwrap returns ErrSyntax
+func (e *NumberError) Unwrap() error {
+	return ErrSyntax
+}
+
+type numberToken struct {
+	*scalar
+}
 
 func (n numberToken) Type() Type {
 	return NumberType
 }
 
 func (n numberToken) Unmarshal(b interface{}) error {
-	i, err := strconv.ParseInt(string(n), 10, 64)
+	v, err := n.unmarshalTarget(b, "number")
 	if err != nil {
 		return err
 	}
+	if ok, err := unmarshaler(n, v); ok {
+		return err
+	}
 
-	reflect.Indirect(reflect.ValueOf(b)).SetInt(i)
-
-	return nil
+	return n.setNumber(v, string(n.text))
 }
 
 func (n numberToken) String() string {
-	return fmt.Sprintf("%s", string(n))
+	return string(n.text)
 }
 
-type floatToken string
+type floatToken struct {
+	*scalar
+}
 
 func (n floatToken) Type() Type {
 	return NumberType
 }
 
 func (n floatToken) Unmarshal(b interface{}) error {
-	i, err := strconv.ParseFloat(string(n), 64)
+	v, err := n.unmarshalTarget(b, "number")
 	if err != nil {
 		return err
 	}
+	if ok, err := unmarshaler(n, v); ok {
+		return err
+	}
 
-	reflect.Indirect(reflect.ValueOf(b)).SetFloat(i)
-
-	return nil
+	return n.setNumber(v, string(n.text))
 }
 
 func (n floatToken) String() string {
-	return fmt.Sprintf("%s", string(n))
+	return string(n.text)
 }
@@ -1,25 +1,58 @@
 package sjson
 
-import "bufio"
+import (
+	"errors"
+	"io"
+)
 
+// An objectToken reads an object through the frame of its level of nesting,
+// which holds its position and path
 type objectToken struct {
-	r   *bufio.Reader
-	dec *Decoder
+	*frame
 }
 
-func (o *objectToken) Type() Type {
+func (o objectToken) Type() Type {
 	return ObjectType
 }
 
-func (o *objectToken) Next() (Token, error) {
-	if o.dec == nil {
-		o.dec = newDecoder(o.r)
-		o.dec.initialState = o.dec.objectBeginState
+func (o objectToken) Next() (Token, error) {
+	if o.done {
+		return nil, io.EOF
 	}
+	o.started = true
 
-	return o.dec.Next()
+	t, err := o.next()
+	if err == nil && t.Type() == EndType {
+		o.done = true
+	}
+
+	return t, err
+}
+
+// Skip consumes the rest of the object without decoding it
+func (o objectToken) Skip() error {
+	if o.done {
+		return nil
+	}
+
+	o.done = true
+	return o.annotate(o.r.skipTo(o.depth - 1))
+}
+
+// Raw consumes the object and returns its exact bytes. It must be
+// called before any members are read.
+func (o objectToken) Raw() ([]byte, error) {
+	if o.done || o.started {
+		return nil, errors.New("Raw called on a partially read object")
+	}
+
+	o.done = true
+	raw, err := o.r.captureRaw("{", func() error {
+		return o.r.skipTo(o.depth - 1)
+	})
+	return raw, o.annotate(err)
 }
 
-func (o *objectToken) String() string {
+func (o objectToken) String() string {
 	return "objectToken"
 }
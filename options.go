@@ -0,0 +1,144 @@
+package sjson
+
+import (
+	"io"
+)
+
+// A Mode selects how many top-level values the input may hold
+type Mode int
+
+// The decoding modes
+const (
+	// Concatenated accepts any number of top-level values, one after
+	// the other
+	Concatenated Mode = iota
+
+	// Strict accepts exactly one top-level value, followed only by
+	// whitespace
+	Strict
+
+	// Sequence reads a JSON text sequence (RFC 7464): values each
+	// preceded by a record separator (0x1E). Truncated or invalid
+	// records are dropped.
+	Sequence
+)
+
+// A Syntax selects the dialect of JSON accepted by the decoder
+type Syntax int
+
+// The syntaxes
+const (
+	// JSON is standard JSON (RFC 8259)
+	JSON Syntax = iota
+
+	// JSONC adds // and /* */ comments and trailing commas
+	JSONC
+
+	// JSON5 adds the rest of the JSON5 grammar to JSONC: unquoted keys,
+	// single-quoted and multi-line strings, hexadecimal numbers,
+	// Infinity, NaN, leading '+' and leading or trailing decimal
+	// points, and more whitespace and escapes
+	JSON5
+)
+
+// DecoderOptions configures a decoder and limits the resources it may
+// spend on its input. A zero limit means no limit. Exceeding a limit
+// fails with a *LimitExceededError naming the limit and the path of the
+// value.
+type DecoderOptions struct {
+	// Mode selects how many top-level values are accepted
+	Mode Mode
+
+	// Syntax selects the dialect of JSON accepted
+	Syntax Syntax
+
+	// Comments makes Next return comments as CommentType tokens in the
+	// JSONC and JSON5 syntaxes. Comments between the key and the value
+	// of a member are returned after the member.
+	Comments bool
+
+	// ReuseTokens makes the decoder reuse its tokens to avoid allocating
+	// them: the text of a string, number or literal name is then valid
+	// until the next read, and an object or array until its parent moves
+	// past it. Otherwise each token stays valid once read.
+	ReuseTokens bool
+
+	// OnDroppedRecord is called in Sequence mode with each record that
+	// is dropped
+	OnDroppedRecord func(err *RecordError)
+
+	// MaxDepth is the maximum nesting of objects and arrays
+	MaxDepth int
+
+	// MaxStringLength is the maximum length in bytes of an unescaped
+	// string, including object keys. A key that is too long is given in
+	// the path of the error by its first MaxStringLength bytes.
+	MaxStringLength int
+
+	// MaxNumberLength is the maximum length in bytes of a number
+	MaxNumberLength int
+
+	// MaxMembersPerObject is the maximum number of members of an object
+	MaxMembersPerObject int
+
+	// MaxTotalBytes is the maximum number of bytes read from the input
+	MaxTotalBytes int64
+
+	// MaxTokens is the maximum number of tokens returned by Next,
+	// counting a member and its value as one token
+	MaxTokens int64
+
+	// MaxBufferedBytes is the maximum number of bytes of input held in
+	// memory: those of the token or raw value being read, and those kept
+	// by a JSONPath query until their turn
+	MaxBufferedBytes int64
+}
+
+// NewDecoderWithOptions creates a new decoder from the given reader
+// with the given limits
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	if opts.MaxTotalBytes > 0 {
+		r = &limitReader{r: r, n: opts.MaxTotalBytes, max: opts.MaxTotalBytes}
+	}
+
+	dec := NewDecoder(r)
+	dec.r.opts = opts
+	return dec
+}
+
+// limitError creates a LimitExceededError for the limit at the current
+// position
+func (r *reader) limitError(limit string, max int64) *LimitExceededError {
+	return &LimitExceededError{Limit: limit, Max: max, Position: r.position()}
+}
+
+// limitReader fails with a LimitExceededError once more than max bytes
+// have been read
+type limitReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// the limit is only exceeded if there is more input
+		var b [1]byte
+		for {
+			n, err := l.r.Read(b[:])
+			if n > 0 {
+				return 0, &LimitExceededError{Limit: "MaxTotalBytes", Max: l.max}
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
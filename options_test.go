@@ -0,0 +1,198 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// decodeAll reads every token of the input, returning the first error
+func decodeAll(in string, opts DecoderOptions) error {
+	dec := NewDecoderWithOptions(strings.NewReader(in), opts)
+
+	for t, err := range dec.All() {
+		if err != nil {
+			return err
+		}
+		if ct, ok := t.(ComplexToken); ok {
+			if err := drain(ct); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestDecoderOptions(t *testing.T) {
+
+	Convey("Each limit should fail with a LimitExceededError at the offending path", t, func() {
+		cases := []struct {
+			in    string
+			opts  DecoderOptions
+			limit string
+			path  string
+		}{
+			{`{"a": [[1]]}`, DecoderOptions{MaxDepth: 2}, "MaxDepth", "/a/0"},
+			{`["ok", "too long"]`, DecoderOptions{MaxStringLength: 4}, "MaxStringLength", "/1"},
+			{`{"a\n": 1, "long\n": 2}`, DecoderOptions{MaxStringLength: 4}, "MaxStringLength", "/long"},
+			{`{"a": {"` + strings.Repeat("k", 10000) + `": 1}}`, DecoderOptions{MaxStringLength: 8}, "MaxStringLength", "/a/kkkkkkkk"},
+			{`{"a": {"ké": 1}}`, DecoderOptions{MaxStringLength: 2}, "MaxStringLength", "/a/k"},
+			{`{a: {` + strings.Repeat("k", 100) + `: 1}}`, DecoderOptions{Syntax: JSON5, MaxStringLength: 8}, "MaxStringLength", "/a/kkkkkkkk"},
+			{`[1, 12345]`, DecoderOptions{MaxNumberLength: 4}, "MaxNumberLength", "/1"},
+			{`[1, 0x` + strings.Repeat("f", 100000) + `]`, DecoderOptions{Syntax: JSON5, MaxNumberLength: 10}, "MaxNumberLength", "/1"},
+			{`{"a": {"b": 1, "c": 2, "d": 3}}`, DecoderOptions{MaxMembersPerObject: 2}, "MaxMembersPerObject", "/a/d"},
+			{`{"a": [1, 2, 3, 4]}`, DecoderOptions{MaxTokens: 4}, "MaxTokens", "/a/2"},
+			{`{"a": [1, 2, 3, 4]}`, DecoderOptions{MaxTotalBytes: 12}, "MaxTotalBytes", "/a/2"},
+			{`{"a": ["` + strings.Repeat("x", 10000) + `"]}`, DecoderOptions{MaxBufferedBytes: 4096}, "MaxBufferedBytes", "/a/0"},
+		}
+
+		for _, c := range cases {
+			err := decodeAll(c.in, c.opts)
+
+			var le *LimitExceededError
+			So(errors.As(err, &le), ShouldBeTrue)
+			So(errors.Is(err, ErrLimitExceeded), ShouldBeTrue)
+			So(le.Limit, ShouldEqual, c.limit)
+			So(le.Path, ShouldEqual, c.path)
+		}
+	})
+
+	Convey("Input within the limits should decode normally", t, func() {
+		in := `{"a": [[1]], "b": "four", "c": 1234}`
+		opts := DecoderOptions{
+			MaxDepth:            3,
+			MaxStringLength:     4,
+			MaxNumberLength:     4,
+			MaxMembersPerObject: 3,
+			MaxTotalBytes:       int64(len(in)),
+			MaxTokens:           9,
+			MaxBufferedBytes:    64,
+		}
+
+		So(decodeAll(in, opts), ShouldBeNil)
+	})
+
+	Convey("Deeply nested input should stop at MaxDepth", t, func() {
+		in := strings.Repeat("[", 1000000)
+
+		err := decodeAll(in, DecoderOptions{MaxDepth: 100})
+
+		var le *LimitExceededError
+		So(errors.As(err, &le), ShouldBeTrue)
+		So(le.Position.Offset, ShouldEqual, 100)
+	})
+}
+
+func TestDecoderModes(t *testing.T) {
+
+	Convey("Strict mode should accept one value followed by whitespace", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(` {"a": [1]} `+"\n"), DecoderOptions{Mode: Strict})
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(drain(t.(ComplexToken)), ShouldBeNil)
+
+		_, err = dec.Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Strict mode should reject trailing data", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(`{"a": 1} {"b": 2}`), DecoderOptions{Mode: Strict})
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		var se *SyntaxError
+		So(errors.As(drain(t.(ComplexToken)), &se), ShouldBeTrue)
+		So(se.Expected, ShouldEqual, "end of input")
+		So(se.Position.Offset, ShouldEqual, 9)
+		So(se.Path, ShouldEqual, "")
+
+		dec = NewDecoderWithOptions(strings.NewReader(`{"a": [1]} x`), DecoderOptions{Mode: Strict})
+		t, err = dec.Next()
+		So(err, ShouldBeNil)
+		So(errors.As(drain(t.(ComplexToken)), &se), ShouldBeTrue)
+		So(se.Path, ShouldEqual, "")
+		So(se.Error(), ShouldNotContainSubstring, "/a")
+
+		dec = NewDecoderWithOptions(strings.NewReader(`1 2`), DecoderOptions{Mode: Strict})
+		_, err = dec.Next()
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+	})
+
+	Convey("Strict mode should skip the rest of a partly read value", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(`[1, [2], 3] x`), DecoderOptions{Mode: Strict})
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		_, err = t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+
+		_, err = dec.Next()
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+	})
+
+	Convey("Strict mode should require a value", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(" \n"), DecoderOptions{Mode: Strict})
+
+		_, err := dec.Next()
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+
+	Convey("Concatenated mode should accept back-to-back values", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(`{}[]1"a"true{"b":null}`), DecoderOptions{})
+
+		var types []Type
+		for t, err := range dec.All() {
+			So(err, ShouldBeNil)
+			types = append(types, t.Type())
+		}
+		So(types, ShouldResemble, []Type{ObjectType, ArrayType, NumberType, StringType, BoolType, ObjectType})
+	})
+
+	Convey("Concatenated mode should skip the rest of a partly read value", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"a": 1} {"b": [2, 3]} 4`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ObjectType)
+
+		t, err = dec.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ObjectType)
+		t, err = t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+		So(t.(*MemberToken).Key, ShouldEqual, "b")
+		_, err = t.(*MemberToken).Value.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+
+		var n int
+		So(dec.Decode(&n), ShouldBeNil)
+		So(n, ShouldEqual, 4)
+
+		_, err = dec.Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("A closing brace in place of a value should be an error", t, func() {
+		for _, in := range []string{`}`, `[1, }`, `{"a": }`, `[}`} {
+			err := decodeAll(in, DecoderOptions{})
+			So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+		}
+	})
+
+	Convey("DisallowTrailingData should report data after the decoded value", t, func() {
+		var v map[string]int
+
+		dec := NewDecoder(strings.NewReader(`{"a": 1}  `))
+		So(dec.Decode(&v), ShouldBeNil)
+		So(dec.DisallowTrailingData(), ShouldBeNil)
+
+		dec = NewDecoder(strings.NewReader(`{"a": 1} x`))
+		So(dec.Decode(&v), ShouldBeNil)
+		So(errors.Is(dec.DisallowTrailingData(), ErrSyntax), ShouldBeTrue)
+	})
+}
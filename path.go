@@ -0,0 +1,145 @@
+package sjson
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A PathElement is one step of the path to a value: the key of an
+// object member or the index of an array element
+type PathElement struct {
+	Key string
+
+	// Index is the index of an array element, or -1 for a member
+	Index int
+}
+
+// String returns the key, or the index in decimal
+func (e PathElement) String() string {
+	if e.Index < 0 {
+		return e.Key
+	}
+	return strconv.Itoa(e.Index)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// kinds of pathNode
+const (
+	rootNode = iota
+	keyNode
+	indexNode
+)
+
+// A pathNode is one step of the path to a value: a member key or an
+// array index below its parent. Nodes are linked to the node of their
+// containing object or array, so the full path is only built when it is
+// asked for.
+type pathNode struct {
+	parent *pathNode
+	kind   uint8
+	key    string
+	index  int
+}
+
+// detached returns a copy of the node with copies of its parents
+func (n pathNode) detached() pathNode {
+	if n.parent != nil {
+		p := n.parent.detached()
+		n.parent = &p
+	}
+	return n
+}
+
+// pointer returns the JSON Pointer of the node
+func (n *pathNode) pointer() string {
+	if n == nil || n.kind == rootNode {
+		return ""
+	}
+
+	var parts []string
+	for ; n != nil && n.kind != rootNode; n = n.parent {
+		if n.kind == keyNode {
+			parts = append(parts, pointerEscaper.Replace(n.key))
+		} else {
+			parts = append(parts, strconv.Itoa(n.index))
+		}
+	}
+
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteByte('/')
+		b.WriteString(parts[i])
+	}
+	return b.String()
+}
+
+// elements returns the path of the node, nil for the top-level value
+func (n *pathNode) elements() []PathElement {
+	var path []PathElement
+	for ; n != nil && n.kind != rootNode; n = n.parent {
+		if n.kind == keyNode {
+			path = append(path, PathElement{Key: n.key, Index: -1})
+		} else {
+			path = append(path, PathElement{Index: n.index})
+		}
+	}
+
+	slices.Reverse(path)
+	return path
+}
+
+// normalized returns the normalized JSONPath (RFC 9535) of the node,
+// such as $['a'][0]
+func (n *pathNode) normalized() string {
+	var nodes []*pathNode
+	for ; n != nil && n.kind != rootNode; n = n.parent {
+		nodes = append(nodes, n)
+	}
+
+	var b strings.Builder
+	b.WriteByte('$')
+	for i := len(nodes) - 1; i >= 0; i-- {
+		b.WriteByte('[')
+		if nodes[i].kind == keyNode {
+			writeNormalizedName(&b, nodes[i].key)
+		} else {
+			b.WriteString(strconv.Itoa(nodes[i].index))
+		}
+		b.WriteByte(']')
+	}
+	return b.String()
+}
+
+// writeNormalizedName writes a member name as a single-quoted string
+// with the escapes of normalized paths
+func writeNormalizedName(b *strings.Builder, name string) {
+	b.WriteByte('\'')
+	for _, c := range name {
+		switch c {
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('\'')
+}
@@ -0,0 +1,87 @@
+package sjson
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPath(t *testing.T) {
+
+	const doc = `{"orders": [{"id": 1}, {"lines": [{"sku": "a"}, {"a/b~c": "b"}]}]}`
+
+	Convey("Every token should report its path", t, func() {
+		dec := NewDecoder(strings.NewReader(doc))
+
+		var pointers []string
+		var walk func(ct ComplexToken)
+		walk = func(ct ComplexToken) {
+			for {
+				t, err := ct.Next()
+				So(err, ShouldBeNil)
+				pointers = append(pointers, t.Pointer())
+				if t.Type() == EndType {
+					return
+				}
+
+				if mt, ok := t.(*MemberToken); ok {
+					So(mt.Value.Pointer(), ShouldEqual, mt.Pointer())
+					t = mt.Value
+				}
+				if c, ok := t.(ComplexToken); ok {
+					walk(c)
+				}
+			}
+		}
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(t.Pointer(), ShouldEqual, "")
+		So(t.Path(), ShouldBeEmpty)
+		walk(t.(ComplexToken))
+
+		So(pointers, ShouldResemble, []string{
+			"/orders",
+			"/orders/0", "/orders/0/id", "/orders/0",
+			"/orders/1", "/orders/1/lines",
+			"/orders/1/lines/0", "/orders/1/lines/0/sku", "/orders/1/lines/0",
+			"/orders/1/lines/1", "/orders/1/lines/1/a~1b~0c", "/orders/1/lines/1",
+			"/orders/1/lines", "/orders/1", "/orders", "",
+		})
+	})
+
+	Convey("Paths should list keys and indices", t, func() {
+		t, err := Find(strings.NewReader(doc), "/orders/1/lines/0/sku")
+		So(err, ShouldBeNil)
+		So(t.Path(), ShouldResemble, []PathElement{
+			{Key: "orders", Index: -1},
+			{Index: 1},
+			{Key: "lines", Index: -1},
+			{Index: 0},
+			{Key: "sku", Index: -1},
+		})
+
+		var parts []string
+		for _, e := range t.Path() {
+			parts = append(parts, e.String())
+		}
+		So(strings.Join(parts, "."), ShouldEqual, "orders.1.lines.0.sku")
+	})
+
+	Convey("The decoder should report the path of the last token read", t, func() {
+		dec := NewDecoder(strings.NewReader(doc))
+		So(dec.Pointer(), ShouldEqual, "")
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		orders, err := t.(ComplexToken).Next()
+		So(err, ShouldBeNil)
+
+		arr := orders.(*MemberToken).Value.(ComplexToken)
+		_, err = arr.Next()
+		So(err, ShouldBeNil)
+		So(dec.Pointer(), ShouldEqual, "/orders/0")
+		So(dec.Path(), ShouldResemble, []PathElement{{Key: "orders", Index: -1}, {Index: 0}})
+	})
+}
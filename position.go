@@ -0,0 +1,282 @@
+package sjson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// A Position is a location in the input stream. Line and Column are
+// 1-based and Column counts bytes, not runes.
+type Position struct {
+	Offset int64
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d (offset %d)", p.Line, p.Column, p.Offset)
+}
+
+// snippetSize is the number of bytes kept on either side of the
+// current position for error snippets
+const snippetSize = 16
+
+// minRead is the size of the reads from the input
+const minRead = 4096
+
+// reader buffers the input and keeps track of the position of the
+// bytes it consumes. It is shared between a decoder and all of its
+// objects and arrays so they all agree on the position.
+type reader struct {
+	src io.Reader
+
+	// err is the error returned by src, reported once the bytes read
+	// before it are consumed
+	err error
+
+	// buf holds the input from offset base on, of which buf[off:] is
+	// unread. A few consumed bytes are kept for error snippets, and all
+	// of them while a mark is held.
+	buf  []byte
+	off  int
+	base int64
+
+	// the lines of the consumed bytes are counted when a position is
+	// asked for: line is the line of buf[counted], which begins at
+	// offset lineStart
+	line      int
+	lineStart int64
+	counted   int
+
+	// level is the number of objects and arrays that have been opened
+	// and not yet closed
+	level int
+
+	// marks counts the marks held, and held is the offset of the first
+	marks int
+	held  int64
+
+	// pinned keeps the buffered bytes where they are, so that the text
+	// of the last token stays valid while reading ahead
+	pinned bool
+
+	// opts holds the limits of the decoder, and tokens counts the
+	// tokens returned so far
+	opts   DecoderOptions
+	tokens int64
+
+	// record counts the records of a sequence, and recordErr holds the
+	// first error of the current one
+	record    int
+	recordErr error
+
+	// current is the last token read, for its path
+	current Token
+
+	// keys holds the strings of the member keys seen so far, see intern
+	keys map[string]string
+
+	// text holds the unescaped text of the last string that could not
+	// be returned as a part of buf
+	text bytes.Buffer
+
+	// frames holds the frames of the objects and arrays, one for each
+	// level of nesting, see frameAt
+	frames []*frame
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{src: r, line: 1}
+}
+
+// setOrigin makes the reader count positions from p, for input that
+// starts in the middle of a larger one
+func (r *reader) setOrigin(p Position) {
+	r.base = p.Offset
+	r.line = p.Line
+	r.lineStart = p.Offset - int64(p.Column-1)
+}
+
+// fill reads more input into buf, moving the bytes that are still
+// needed to its start or to a larger buffer
+func (r *reader) fill() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	needed := r.off
+	if r.marks > 0 {
+		needed = int(r.held - r.base)
+	}
+	if max := r.opts.MaxBufferedBytes; max > 0 && int64(len(r.buf)-needed) >= max {
+		return r.limitError("MaxBufferedBytes", max)
+	}
+
+	if len(r.buf)+minRead > cap(r.buf) {
+		keep := r.off - snippetSize
+		if needed < keep {
+			keep = needed
+		}
+		if keep < 0 {
+			keep = 0
+		}
+
+		n := len(r.buf) - keep
+		r.countLines()
+		buf := r.buf
+		if r.pinned || n+minRead > cap(buf) {
+			buf = make([]byte, 2*cap(buf)+minRead)
+		}
+		copy(buf, r.buf[keep:])
+		r.buf = buf[:n]
+		r.off -= keep
+		r.counted -= keep
+		r.base += int64(keep)
+		needed -= keep
+	}
+
+	// read no more than MaxBufferedBytes allows
+	end := cap(r.buf)
+	if max := r.opts.MaxBufferedBytes; max > 0 && int64(end-needed) > max {
+		end = needed + int(max)
+	}
+
+	for i := 0; i < 100; i++ {
+		n, err := r.src.Read(r.buf[len(r.buf):end])
+		r.buf = r.buf[:len(r.buf)+n]
+		if err != nil {
+			r.err = err
+		}
+		if n > 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	r.err = io.ErrNoProgress
+	return r.err
+}
+
+// Peek returns the next n bytes without consuming them, or fewer and
+// the error that stopped the input short of them
+func (r *reader) Peek(n int) ([]byte, error) {
+	for len(r.buf)-r.off < n {
+		if err := r.fill(); err != nil {
+			return r.buf[r.off:], err
+		}
+	}
+	return r.buf[r.off : r.off+n], nil
+}
+
+// Discard consumes the next n bytes
+func (r *reader) Discard(n int) (int, error) {
+	d, err := r.Peek(n)
+	r.off += len(d)
+	return len(d), err
+}
+
+// ReadByte consumes and returns a single byte
+func (r *reader) ReadByte() (byte, error) {
+	if r.off == len(r.buf) {
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	b := r.buf[r.off]
+	r.off++
+	return b, nil
+}
+
+// offset returns the offset of the next byte in the input
+func (r *reader) offset() int64 {
+	return r.base + int64(r.off)
+}
+
+// position returns the position of the next byte in the input
+func (r *reader) position() Position {
+	r.countLines()
+	off := r.offset()
+	return Position{Offset: off, Line: r.line, Column: int(off-r.lineStart) + 1}
+}
+
+// countLines counts the lines of the bytes consumed since it was last
+// called
+func (r *reader) countLines() {
+	d := r.buf[r.counted:r.off]
+	if len(d) < 64 {
+		// most tokens are a few bytes apart
+		for i, b := range d {
+			if b == '\n' {
+				r.line++
+				r.lineStart = r.base + int64(r.counted+i+1)
+			}
+		}
+	} else if i := bytes.LastIndexByte(d, '\n'); i >= 0 {
+		r.line += bytes.Count(d[:i+1], newline)
+		r.lineStart = r.base + int64(r.counted+i+1)
+	}
+	r.counted = r.off
+}
+
+var newline = []byte{'\n'}
+
+// mark starts keeping the bytes consumed from here on in buf, until
+// release returns them. Marks nest, and each one must be released.
+func (r *reader) mark() int64 {
+	if r.marks == 0 {
+		r.held = r.offset()
+	}
+	r.marks++
+	return r.offset()
+}
+
+// release stops keeping the bytes consumed since the mark at start and
+// returns them. They stay valid until the next read.
+func (r *reader) release(start int64) []byte {
+	r.marks--
+	return r.buf[start-r.base : r.off]
+}
+
+// snippet returns the bytes surrounding the current position, with
+// the current position marked by a caret
+func (r *reader) snippet() string {
+	after, _ := r.Peek(snippetSize)
+
+	start := r.off - snippetSize
+	if start < 0 {
+		start = 0
+	}
+	return fmt.Sprintf("%s^%s", r.buf[start:r.off], after)
+}
+
+// syntaxErrorf creates a SyntaxError at the current position
+func (r *reader) syntaxErrorf(format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{
+		Msg:      fmt.Sprintf(format, args...),
+		Position: r.position(),
+		Snippet:  r.snippet(),
+	}
+}
+
+// unexpected creates a SyntaxError for finding found when expecting
+// expected
+func (r *reader) unexpected(found []byte, expected string) *SyntaxError {
+	f := string(found)
+	return &SyntaxError{
+		Msg:      "Unexpected token " + f,
+		Expected: expected,
+		Found:    f,
+		Position: r.position(),
+		Snippet:  r.snippet(),
+	}
+}
+
+// eofError creates an UnexpectedEOFError at the current position
+func (r *reader) eofError(msg string) *UnexpectedEOFError {
+	return &UnexpectedEOFError{
+		Msg:      msg,
+		Position: r.position(),
+	}
+}
@@ -1,11 +1,19 @@
 package sjson
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
-// ReadAll reads the entire body and sends each token to the channel
+// ReadAll reads the entire body and sends each token to the channel.
+// The tokens are copies that stay valid once the decoder reads on, and
+// objects and arrays are sent as read, their contents following them.
 func ReadAll(r io.Reader, ch chan<- Token) (err error) {
 	dec := NewDecoder(r)
-	err = readAll(dec, ch)
+	err = readAll(dec, func(t Token) error {
+		ch <- detach(t)
+		return nil
+	})
 
 	// hide EOFs
 	if err == io.EOF {
@@ -15,53 +23,97 @@ func ReadAll(r io.Reader, ch chan<- Token) (err error) {
 	return
 }
 
-func readAll(ct ComplexToken, ch chan<- Token) error {
-	t, err := ct.Next()
+// A ReadAllOption configures ReadAllContext
+type ReadAllOption func(*readAllConfig)
 
-	if err != nil || t == nil {
-		return err
-	}
+type readAllConfig struct {
+	close bool
+}
 
-	ch <- t
+// CloseChannel makes ReadAllContext close the channel when it returns,
+// whether or not it succeeded
+func CloseChannel() ReadAllOption {
+	return func(c *readAllConfig) {
+		c.close = true
+	}
+}
 
-	if t.Type() == EndType {
-		return nil
+// ReadAllContext is like ReadAll but stops when ctx is done, returning
+// ctx.Err(). It never blocks on a channel send after ctx is done, so a
+// consumer that stops reading can cancel ctx to release the producer.
+func ReadAllContext(ctx context.Context, r io.Reader, ch chan<- Token, opts ...ReadAllOption) (err error) {
+	var c readAllConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.close {
+		defer close(ch)
 	}
 
-	// simple type
-	if t.Type() < 5 {
-		return readAll(ct, ch)
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// complex type
-	if t.Type() < 7 {
-		err := readAll(t.(ComplexToken), ch)
-		if err != nil {
+	dec := NewDecoder(r)
+	err = readAll(dec, func(t Token) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+
+		select {
+		case ch <- detach(t):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	// hide EOFs
+	if err == io.EOF {
+		err = nil
 	}
 
-	if t.Type() == MemberType {
+	return
+}
+
+// readAll sends every token read from ct. Objects and arrays are
+// tracked on an explicit stack, so memory use is bounded by the nesting
+// depth rather than the number of tokens.
+func readAll(ct ComplexToken, send func(Token) error) error {
+	stack := []ComplexToken{ct}
 
-		// member type
-		mt := t.(*MemberToken)
-		t2 := mt.Value
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
 
-		// simple type
-		if t2.Type() < 5 {
-			//ch <- t2
-			return readAll(ct, ch)
+		t, err := top.Next()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if err := send(t); err != nil {
+			return err
 		}
 
-		// complex type
-		if t2.Type() < 7 {
-			ch <- t2
-			err := readAll(t2.(ComplexToken), ch)
-			if err != nil {
-				return err
+		switch t.Type() {
+		case EndType:
+			stack = stack[:len(stack)-1]
+		case ObjectType, ArrayType:
+			stack = append(stack, t.(ComplexToken))
+		case MemberType:
+			// complex member values are sent after their member
+			v := t.(*MemberToken).Value
+			if v.Type() == ObjectType || v.Type() == ArrayType {
+				if err := send(v); err != nil {
+					return err
+				}
+				stack = append(stack, v.(ComplexToken))
 			}
 		}
 	}
 
-	return readAll(ct, ch)
+	return nil
 }
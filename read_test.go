@@ -0,0 +1,143 @@
+package sjson
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadAll(t *testing.T) {
+
+	Convey("ReadAll should send every token", t, func() {
+		ch := make(chan Token, 16)
+		err := ReadAll(strings.NewReader(`{"a": [1, 2], "b": 3}`), ch)
+		close(ch)
+		So(err, ShouldBeNil)
+
+		var types []Type
+		for t := range ch {
+			types = append(types, t.Type())
+		}
+		So(types, ShouldResemble, []Type{
+			ObjectType, MemberType, ArrayType, NumberType, NumberType, EndType, MemberType, EndType,
+		})
+	})
+
+	Convey("ReadAllContext should close the channel when asked", t, func() {
+		ch := make(chan Token)
+		done := make(chan error)
+		go func() {
+			done <- ReadAllContext(context.Background(), strings.NewReader(`[1, 2]`), ch, CloseChannel())
+		}()
+
+		n := 0
+		for range ch {
+			n++
+		}
+		So(n, ShouldEqual, 4)
+		So(<-done, ShouldBeNil)
+	})
+
+	Convey("ReadAllContext should stop when the consumer cancels", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		ch := make(chan Token)
+		done := make(chan error)
+		go func() {
+			done <- ReadAllContext(ctx, strings.NewReader(`[1, 2, 3, 4, 5]`), ch, CloseChannel())
+		}()
+
+		<-ch
+		<-ch
+		cancel()
+
+		select {
+		case err := <-done:
+			So(err, ShouldEqual, context.Canceled)
+		case <-time.After(5 * time.Second):
+			So("ReadAllContext did not return", ShouldBeEmpty)
+		}
+
+		_, more := <-ch
+		So(more, ShouldBeFalse)
+	})
+
+	Convey("ReadAllContext should return decoding errors", t, func() {
+		ch := make(chan Token, 16)
+		err := ReadAllContext(context.Background(), strings.NewReader(`[1, :]`), ch, CloseChannel())
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// arrayReader generates a JSON array of n zeros without holding it in
+// memory
+type arrayReader struct {
+	n, i int
+	open bool
+}
+
+func (a *arrayReader) Read(p []byte) (int, error) {
+	var w int
+	if !a.open && w < len(p) {
+		p[w] = '['
+		w++
+		a.open = true
+	}
+	for ; a.i < a.n && w+2 <= len(p); a.i++ {
+		p[w] = '0'
+		if a.i == a.n-1 {
+			p[w+1] = ']'
+		} else {
+			p[w+1] = ','
+		}
+		w += 2
+	}
+	if w == 0 {
+		return 0, io.EOF
+	}
+	return w, nil
+}
+
+func BenchmarkReadAllLargeArray(b *testing.B) {
+	const n = 10000000
+
+	b.ReportAllocs()
+
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		ch := make(chan Token, 1024)
+		done := make(chan uint64)
+		go func() {
+			var ms runtime.MemStats
+			var max uint64
+			var count int
+			for range ch {
+				count++
+				if count%1000000 == 0 {
+					runtime.ReadMemStats(&ms)
+					if ms.HeapInuse > max {
+						max = ms.HeapInuse
+					}
+				}
+			}
+			done <- max
+		}()
+
+		err := ReadAll(&arrayReader{n: n}, ch)
+		close(ch)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if max := <-done; max > peak {
+			peak = max
+		}
+	}
+
+	b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+}
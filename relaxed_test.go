@@ -0,0 +1,143 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// flatten reads every token of in, descending into objects and arrays,
+// and returns their text
+func flatten(in string, opts DecoderOptions) ([]string, error) {
+	var out []string
+
+	var walk func(ct interface{ Next() (Token, error) }) error
+	walk = func(ct interface{ Next() (Token, error) }) error {
+		for {
+			t, err := ct.Next()
+			if err != nil {
+				return err
+			}
+
+			if mt, ok := t.(*MemberToken); ok {
+				out = append(out, mt.Key+":")
+				t = mt.Value
+			}
+			switch t.Type() {
+			case EndType:
+				out = append(out, "end")
+				return nil
+			case ObjectType, ArrayType:
+				out = append(out, t.Type().String())
+				if err := walk(t.(ComplexToken)); err != nil {
+					return err
+				}
+			default:
+				out = append(out, toText(t))
+			}
+		}
+	}
+
+	err := walk(NewDecoderWithOptions(strings.NewReader(in), opts))
+	if err == io.EOF {
+		err = nil
+	}
+	return out, err
+}
+
+func TestRelaxedSyntax(t *testing.T) {
+
+	Convey("JSONC should skip comments", t, func() {
+		in := "// head\n{\"a\": /* one */ 1, /* b */ \"b\": [2, // two\n 3]} /* tail */"
+		got, err := flatten(in, DecoderOptions{Syntax: JSONC})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"ObjectType", "a:", "1", "b:", "ArrayType", "2", "3", "end", "end"})
+	})
+
+	Convey("JSONC should return comments as tokens when asked", t, func() {
+		in := "// head\n{\"a\": /* one */ 1, /* b */ \"b\": [2, // two\n 3]} /* tail */"
+		got, err := flatten(in, DecoderOptions{Syntax: JSONC, Comments: true})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{
+			"// head", "ObjectType", "a:", "1", "/* one */", "/* b */",
+			"b:", "ArrayType", "2", "// two", "3", "end", "end", "/* tail */",
+		})
+	})
+
+	Convey("Relaxed syntaxes should allow trailing commas", t, func() {
+		got, err := flatten(`{"a": [1, 2,], "b": {"c": 3,},}`, DecoderOptions{Syntax: JSONC})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"ObjectType", "a:", "ArrayType", "1", "2", "end", "b:", "ObjectType", "c:", "3", "end", "end"})
+	})
+
+	Convey("Plain JSON should reject relaxed syntax", t, func() {
+		for _, in := range []string{`[1, 2,]`, `{"a": 1,}`, `[1 2]`, `{"a": 1 "b": 2}`, `[1, /* c */ 2]`, `{a: 1}`, `['a']`} {
+			_, err := flatten(in, DecoderOptions{})
+			So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+		}
+
+		_, err := flatten(`{a: 1}`, DecoderOptions{Syntax: JSONC})
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+	})
+
+	Convey("JSON5 should accept its extensions", t, func() {
+		in := "{\n" +
+			"  unquoted: 'single \"quoted\"',\n" +
+			"  $_ident\\u0041: \"multi\\\n line\",\n" +
+			"  'key': [0x1F, -0XFF, +1, .5, 5., Infinity, -Infinity, NaN],\n" +
+			"  esc: '\\x41\\v\\0\\q',\n" +
+			"\v\f\u00a0\ufeff}"
+		got, err := flatten(in, DecoderOptions{Syntax: JSON5})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{
+			"ObjectType",
+			"unquoted:", `single "quoted"`,
+			"$_identA:", "multi line",
+			"key:", "ArrayType", "31", "-255", "1", "0.5", "5.0", "Infinity", "-Infinity", "NaN", "end",
+			"esc:", "A\v\x00q",
+			"end",
+		})
+	})
+
+	Convey("JSON5 should reject malformed numbers", t, func() {
+		for _, in := range []string{`01`, `0x`, `.`, `+-1`, `1e`, `Inf`, "0x" + strings.Repeat("f", 300)} {
+			_, err := flatten(in, DecoderOptions{Syntax: JSON5})
+			So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+		}
+	})
+
+	Convey("Skip and Raw should handle comments and single quotes", t, func() {
+		in := `[{'a': "]", /* ] */ b: '}'} // ]` + "\n" + `, 2]`
+		dec := NewDecoderWithOptions(strings.NewReader(in), DecoderOptions{Syntax: JSON5})
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		arr := t.(ComplexToken)
+
+		raw, err := arr.Raw()
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, in)
+
+		dec = NewDecoderWithOptions(strings.NewReader(in+` 3`), DecoderOptions{Syntax: JSON5})
+		So(dec.Skip(), ShouldBeNil)
+		var n int
+		So(dec.Decode(&n), ShouldBeNil)
+		So(n, ShouldEqual, 3)
+	})
+
+	Convey("Decode should ignore comment tokens", t, func() {
+		in := "/* v */ {\"a\": // x\n [1, /* y */ 2], \"b\": /* z */ \"c\"}"
+		dec := NewDecoderWithOptions(strings.NewReader(in), DecoderOptions{Syntax: JSONC, Comments: true})
+
+		var v struct {
+			A []int
+			B string
+		}
+		So(dec.Decode(&v), ShouldBeNil)
+		So(v.A, ShouldResemble, []int{1, 2})
+		So(v.B, ShouldEqual, "c")
+	})
+}
@@ -0,0 +1,439 @@
+package schema
+
+import (
+	"io"
+	"math"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/sheenobu/sjson"
+)
+
+// defaultMaxEnum is the number of distinct values listed as an enum
+// when Inferrer.MaxEnum is 0
+const defaultMaxEnum = 8
+
+// An Inferrer builds a JSON Schema describing the documents it is
+// given. The zero value is ready to use.
+//
+// The schema lists the types seen at each path, the keys present in
+// every object as required, the range of numbers, the length of
+// strings and arrays, and the format shared by all strings, if any.
+// Values that only take a few distinct values, each seen more than
+// once on average, are listed as an enum.
+type Inferrer struct {
+	// MaxEnum is the largest number of distinct values listed as an
+	// enum. Zero means 8, and a negative value disables enums.
+	MaxEnum int
+
+	root *shape
+}
+
+// kinds of value, in the order they are listed in the type keyword
+const (
+	objectKind = 1 << iota
+	arrayKind
+	stringKind
+	numberKind
+	booleanKind
+	nullKind
+)
+
+var kindNames = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// shape accumulates what was seen at one path
+type shape struct {
+	count int
+	kinds int
+
+	// numbers, and whether they were all integers
+	min, max   float64
+	nonInteger bool
+
+	// strings, and the formats they all have
+	minLength, maxLength int
+	formats              int
+
+	// distinct scalar values, until there are too many or an object or
+	// array is seen
+	values []interface{}
+	noEnum bool
+
+	objects int
+	props   map[string]*shape
+	keys    []string
+
+	arrays             int
+	minItems, maxItems int
+	items              *shape
+}
+
+// formats recognized in strings, in order of preference
+var formats = []struct {
+	name  string
+	match func(s string) bool
+}{
+	{"date-time", func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	}},
+	{"date", func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	}},
+	{"time", func(s string) bool {
+		_, err := time.Parse("15:04:05.999999999Z07:00", s)
+		return err == nil
+	}},
+	{"uuid", uuidPattern.MatchString},
+	{"email", emailPattern.MatchString},
+	{"ipv4", func(s string) bool {
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is4()
+	}},
+	{"ipv6", func(s string) bool {
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is6() && a.Zone() == ""
+	}},
+	{"uri", func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+	}},
+}
+
+var (
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// Add reads the next value from dec and merges it into the schema
+func (in *Inferrer) Add(dec *sjson.Decoder) error {
+	tok, err := next(dec)
+	if err != nil {
+		return err
+	}
+	return in.AddToken(tok)
+}
+
+// AddAll reads every remaining value from dec and merges them into the
+// schema
+func (in *Inferrer) AddAll(dec *sjson.Decoder) error {
+	for {
+		err := in.Add(dec)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// AddNDJSON reads every remaining line from nr and merges its value
+// into the schema
+func (in *Inferrer) AddNDJSON(nr *sjson.NDJSONReader) error {
+	for {
+		tok, err := nr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := in.AddToken(tok); err != nil {
+			return err
+		}
+	}
+}
+
+// AddToken merges the value of tok into the schema, reading objects and
+// arrays to their end
+func (in *Inferrer) AddToken(tok sjson.Token) error {
+	if mt, ok := tok.(*sjson.MemberToken); ok {
+		tok = mt.Value
+	}
+
+	if in.root == nil {
+		in.root = &shape{}
+	}
+	return in.add(in.root, tok)
+}
+
+func (in *Inferrer) maxEnum() int {
+	if in.MaxEnum == 0 {
+		return defaultMaxEnum
+	}
+	return in.MaxEnum
+}
+
+func (in *Inferrer) add(s *shape, tok sjson.Token) error {
+	s.count++
+
+	switch tok.Type() {
+	case sjson.ObjectType:
+		s.kinds |= objectKind
+		return in.object(s, tok.(sjson.ComplexToken))
+	case sjson.ArrayType:
+		s.kinds |= arrayKind
+		return in.array(s, tok.(sjson.ComplexToken))
+	}
+
+	var v interface{}
+	if err := sjson.DecodeToken(tok, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.kinds&stringKind == 0 {
+			s.minLength, s.maxLength = n, n
+			s.formats = 1<<len(formats) - 1
+		}
+		s.kinds |= stringKind
+		s.minLength, s.maxLength = min(s.minLength, n), max(s.maxLength, n)
+
+		for i, f := range formats {
+			if s.formats&(1<<i) != 0 && !f.match(v) {
+				s.formats &^= 1 << i
+			}
+		}
+	case float64:
+		if s.kinds&numberKind == 0 {
+			s.min, s.max = v, v
+		}
+		s.kinds |= numberKind
+		s.min, s.max = math.Min(s.min, v), math.Max(s.max, v)
+		s.nonInteger = s.nonInteger || v != math.Trunc(v)
+	case bool:
+		s.kinds |= booleanKind
+	case nil:
+		s.kinds |= nullKind
+	}
+
+	in.addValue(s, v)
+	return nil
+}
+
+// addValue records a distinct scalar value for enums
+func (in *Inferrer) addValue(s *shape, v interface{}) {
+	if s.noEnum {
+		return
+	}
+
+	for _, seen := range s.values {
+		if reflect.DeepEqual(seen, v) {
+			return
+		}
+	}
+	if len(s.values) >= in.maxEnum() {
+		s.values, s.noEnum = nil, true
+		return
+	}
+	s.values = append(s.values, v)
+}
+
+func (in *Inferrer) object(s *shape, ct sjson.ComplexToken) error {
+	s.objects++
+	s.values, s.noEnum = nil, true
+	if s.props == nil {
+		s.props = make(map[string]*shape)
+	}
+
+	for {
+		tok, err := next(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == sjson.EndType {
+			return nil
+		}
+
+		mt := tok.(*sjson.MemberToken)
+		p, ok := s.props[mt.Key]
+		if !ok {
+			p = &shape{}
+			s.props[mt.Key] = p
+			s.keys = append(s.keys, mt.Key)
+		}
+		if err := in.add(p, mt.Value); err != nil {
+			return err
+		}
+	}
+}
+
+func (in *Inferrer) array(s *shape, ct sjson.ComplexToken) error {
+	s.arrays++
+	s.values, s.noEnum = nil, true
+	if s.items == nil {
+		s.items = &shape{}
+	}
+
+	n := 0
+	for ; ; n++ {
+		tok, err := next(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == sjson.EndType {
+			break
+		}
+		if err := in.add(s.items, tok); err != nil {
+			return err
+		}
+	}
+
+	if s.arrays == 1 {
+		s.minItems, s.maxItems = n, n
+	}
+	s.minItems, s.maxItems = min(s.minItems, n), max(s.maxItems, n)
+	return nil
+}
+
+// Encode writes the schema inferred so far to enc as one value, without
+// flushing it. With no documents added, the schema is true, which
+// accepts any value.
+func (in *Inferrer) Encode(enc *sjson.Encoder) error {
+	if in.root == nil {
+		return enc.Bool(true)
+	}
+
+	w := &schemaWriter{enc: enc}
+	w.begin()
+	w.key("$schema")
+	w.string("https://json-schema.org/draft/2020-12/schema")
+	w.members(in.root)
+	w.end()
+	return w.err
+}
+
+// schemaWriter writes a schema, keeping the first error
+type schemaWriter struct {
+	enc *sjson.Encoder
+	err error
+}
+
+func (w *schemaWriter) do(f func() error) {
+	if w.err == nil {
+		w.err = f()
+	}
+}
+
+func (w *schemaWriter) begin()          { w.do(w.enc.BeginObject) }
+func (w *schemaWriter) beginArray()     { w.do(w.enc.BeginArray) }
+func (w *schemaWriter) end()            { w.do(w.enc.End) }
+func (w *schemaWriter) key(k string)    { w.do(func() error { return w.enc.Key(k) }) }
+func (w *schemaWriter) string(s string) { w.do(func() error { return w.enc.String(s) }) }
+func (w *schemaWriter) int(i int)       { w.do(func() error { return w.enc.Int(int64(i)) }) }
+func (w *schemaWriter) float(f float64) { w.do(func() error { return w.enc.Float(f) }) }
+func (w *schemaWriter) bool(b bool)     { w.do(func() error { return w.enc.Bool(b) }) }
+func (w *schemaWriter) null()           { w.do(w.enc.Null) }
+
+// schema writes the schema of s as an object
+func (w *schemaWriter) schema(s *shape) {
+	w.begin()
+	w.members(s)
+	w.end()
+}
+
+// members writes the keywords of the schema of s
+func (w *schemaWriter) members(s *shape) {
+	var types []string
+	for i, name := range kindNames {
+		if s.kinds&(1<<i) == 0 {
+			continue
+		}
+		if 1<<i == numberKind && !s.nonInteger {
+			name = "integer"
+		}
+		types = append(types, name)
+	}
+
+	w.key("type")
+	if len(types) == 1 {
+		w.string(types[0])
+	} else {
+		w.beginArray()
+		for _, t := range types {
+			w.string(t)
+		}
+		w.end()
+	}
+
+	// an enum is only worth listing if values repeat
+	if len(s.values) > 0 && len(s.values) < s.count {
+		w.key("enum")
+		w.beginArray()
+		for _, v := range s.values {
+			switch v := v.(type) {
+			case string:
+				w.string(v)
+			case float64:
+				w.float(v)
+			case bool:
+				w.bool(v)
+			default:
+				w.null()
+			}
+		}
+		w.end()
+	}
+
+	if s.kinds&numberKind != 0 {
+		w.key("minimum")
+		w.float(s.min)
+		w.key("maximum")
+		w.float(s.max)
+	}
+
+	if s.kinds&stringKind != 0 {
+		w.key("minLength")
+		w.int(s.minLength)
+		w.key("maxLength")
+		w.int(s.maxLength)
+		for i, f := range formats {
+			if s.formats&(1<<i) != 0 {
+				w.key("format")
+				w.string(f.name)
+				break
+			}
+		}
+	}
+
+	if s.kinds&objectKind != 0 {
+		w.key("properties")
+		w.begin()
+		var required []string
+		for _, k := range s.keys {
+			w.key(k)
+			w.schema(s.props[k])
+			if s.props[k].count == s.objects {
+				required = append(required, k)
+			}
+		}
+		w.end()
+
+		if len(required) > 0 {
+			w.key("required")
+			w.beginArray()
+			for _, k := range required {
+				w.string(k)
+			}
+			w.end()
+		}
+	}
+
+	if s.kinds&arrayKind != 0 {
+		if s.items.count > 0 {
+			w.key("items")
+			w.schema(s.items)
+		}
+		w.key("minItems")
+		w.int(s.minItems)
+		w.key("maxItems")
+		w.int(s.maxItems)
+	}
+}
@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sheenobu/sjson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// infer returns the schema inferred by in
+func infer(in *Inferrer) string {
+	var buf bytes.Buffer
+	enc := sjson.NewEncoder(&buf)
+	So(in.Encode(enc), ShouldBeNil)
+	So(enc.Flush(), ShouldBeNil)
+	return strings.TrimSpace(buf.String())
+}
+
+func TestInfer(t *testing.T) {
+
+	Convey("Samples should be merged into one schema", t, func() {
+		in := &Inferrer{}
+		docs := `
+			{"id": 1, "status": "open", "at": "2024-05-01T10:00:00Z", "tags": ["a"], "note": null}
+			{"id": 2, "status": "closed", "at": "2024-05-02T11:30:00+02:00", "tags": [], "price": 1.5}
+			{"id": 3, "status": "open", "at": "2024-05-03T00:00:00Z", "tags": ["b", "c"], "note": "hi"}`
+		So(in.AddAll(sjson.NewDecoder(strings.NewReader(docs))), ShouldBeNil)
+
+		So(infer(in), ShouldEqual, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{`+
+			`"id":{"type":"integer","minimum":1,"maximum":3},`+
+			`"status":{"type":"string","enum":["open","closed"],"minLength":4,"maxLength":6},`+
+			`"at":{"type":"string","minLength":20,"maxLength":25,"format":"date-time"},`+
+			`"tags":{"type":"array","items":{"type":"string","minLength":1,"maxLength":1},"minItems":0,"maxItems":2},`+
+			`"note":{"type":["string","null"],"minLength":2,"maxLength":2},`+
+			`"price":{"type":"number","minimum":1.5,"maximum":1.5}},`+
+			`"required":["id","status","at","tags"]}`)
+	})
+
+	Convey("NDJSON streams should be accepted", t, func() {
+		in := &Inferrer{}
+		lines := "{\"ip\": \"10.0.0.1\", \"n\": [1, {\"a\": true}]}\n{\"ip\": \"192.168.1.1\", \"n\": []}\n"
+		So(in.AddNDJSON(sjson.NewNDJSONReader(strings.NewReader(lines))), ShouldBeNil)
+
+		So(infer(in), ShouldEqual, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{`+
+			`"ip":{"type":"string","minLength":8,"maxLength":11,"format":"ipv4"},`+
+			`"n":{"type":"array","items":{"type":["object","integer"],"minimum":1,"maximum":1,`+
+			`"properties":{"a":{"type":"boolean"}},"required":["a"]},"minItems":0,"maxItems":2}},`+
+			`"required":["ip","n"]}`)
+	})
+
+	Convey("Enums should be limited to a few repeated values", t, func() {
+		in := &Inferrer{MaxEnum: 2}
+		So(in.AddAll(sjson.NewDecoder(strings.NewReader(`["a", "b", "a", "b"] ["c"]`))), ShouldBeNil)
+		So(infer(in), ShouldNotContainSubstring, "enum")
+
+		in = &Inferrer{}
+		So(in.AddAll(sjson.NewDecoder(strings.NewReader(`true false true null`))), ShouldBeNil)
+		So(infer(in), ShouldEqual, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":["boolean","null"],"enum":[true,false,null]}`)
+	})
+
+	Convey("Inferred schemas should accept their samples", t, func() {
+		in := &Inferrer{}
+		docs := `{"a": {"b": [1, 2.5, "x"]}, "c": "u@example.com"} {"a": {}, "c": "v@example.org"} // done`
+		dec := sjson.NewDecoderWithOptions(strings.NewReader(docs), sjson.DecoderOptions{Syntax: sjson.JSONC, Comments: true})
+		So(in.AddAll(dec), ShouldBeNil)
+
+		s, err := Compile([]byte(infer(in)))
+		So(err, ShouldBeNil)
+		So(s.Validate(sjson.NewDecoder(strings.NewReader(`{"a": {"b": ["y", 2, 1]}, "c": "w@example.net"}`))), ShouldBeNil)
+
+		got, err := violations(s, `{"a": {"b": [3]}}`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"/a/b/0 maximum", "/a/b minItems", " required"})
+	})
+
+	Convey("An empty inferrer should accept anything", t, func() {
+		So(infer(&Inferrer{}), ShouldEqual, `true`)
+	})
+
+	Convey("Syntax errors should be returned", t, func() {
+		in := &Inferrer{}
+		So(in.AddAll(sjson.NewDecoder(strings.NewReader(`{"a": }`))), ShouldBeError)
+	})
+}
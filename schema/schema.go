@@ -0,0 +1,305 @@
+// Package schema validates JSON documents against a JSON Schema as they
+// are read by an sjson.Decoder, without building the document in
+// memory.
+//
+// The supported subset of draft 2020-12 is type, enum, const,
+// properties, patternProperties, additionalProperties, required,
+// prefixItems, items, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, minLength, maxLength, pattern, minItems, maxItems,
+// minProperties and maxProperties. Annotations such as title and format
+// are ignored, and other assertions and applicators such as $ref or
+// anyOf are rejected by Compile.
+//
+// An Inferrer builds a schema from sample documents.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sheenobu/sjson"
+)
+
+// ErrInvalidSchema is returned by Compile for schemas that are not
+// valid or use unsupported keywords
+var ErrInvalidSchema = errors.New("schema: invalid schema")
+
+// unsupported lists the draft 2020-12 keywords that Compile rejects
+var unsupported = map[string]bool{
+	"$ref": true, "$dynamicRef": true, "$recursiveRef": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true,
+	"if": true, "then": true, "else": true,
+	"dependentSchemas": true, "dependentRequired": true, "dependencies": true,
+	"propertyNames": true, "unevaluatedProperties": true,
+	"contains": true, "minContains": true, "maxContains": true,
+	"unevaluatedItems": true, "additionalItems": true,
+	"uniqueItems": true, "multipleOf": true,
+}
+
+// A Schema is a compiled JSON Schema. It is safe for concurrent use.
+type Schema struct {
+	// never is set for the false schema, which no value conforms to
+	never bool
+
+	types []string
+
+	// enum holds the values of enum, and constant the value of const
+	enum        []interface{}
+	hasEnum     bool
+	constant    interface{}
+	hasConstant bool
+
+	// deep is set if enum or const includes an object or array, in
+	// which case objects and arrays are decoded to be compared
+	deep bool
+
+	properties           map[string]*Schema
+	patternProperties    []patternSchema
+	additionalProperties *Schema
+	required             []string
+
+	prefixItems []*Schema
+	items       *Schema
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+
+	// counts are -1 when not set
+	minLength, maxLength         int
+	minItems, maxItems           int
+	minProperties, maxProperties int
+
+	pattern *regexp.Regexp
+}
+
+type patternSchema struct {
+	re     *regexp.Regexp
+	schema *Schema
+}
+
+// Compile parses a JSON Schema
+func Compile(data []byte) (*Schema, error) {
+	var v interface{}
+	if err := sjson.NewDecoderWithOptions(bytes.NewReader(data), sjson.DecoderOptions{Mode: sjson.Strict}).Decode(&v); err != nil {
+		return nil, err
+	}
+	return compile(v, "")
+}
+
+// MustCompile is like Compile but panics if the schema is invalid
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// invalid returns an ErrInvalidSchema for the keyword at ptr
+func invalid(ptr, format string, args ...interface{}) error {
+	if ptr == "" {
+		ptr = "/"
+	}
+	return fmt.Errorf("%w: %s at %s", ErrInvalidSchema, fmt.Sprintf(format, args...), ptr)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func compile(v interface{}, ptr string) (*Schema, error) {
+	s := &Schema{
+		minLength: -1, maxLength: -1,
+		minItems: -1, maxItems: -1,
+		minProperties: -1, maxProperties: -1,
+	}
+
+	switch v := v.(type) {
+	case bool:
+		s.never = !v
+		return s, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if err := s.keyword(k, v[k], ptr+"/"+pointerEscaper.Replace(k)); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+	return nil, invalid(ptr, "schema must be an object or a boolean")
+}
+
+// keyword compiles the keyword k with value v, found at ptr
+func (s *Schema) keyword(k string, v interface{}, ptr string) error {
+	if unsupported[k] {
+		return invalid(ptr, "unsupported keyword %s", k)
+	}
+
+	var err error
+	switch k {
+	case "type":
+		s.types, err = compileTypes(v, ptr)
+	case "enum":
+		list, ok := v.([]interface{})
+		if !ok {
+			return invalid(ptr, "enum must be an array")
+		}
+		s.enum, s.hasEnum = list, true
+		for _, e := range list {
+			s.deep = s.deep || isContainer(e)
+		}
+	case "const":
+		s.constant, s.hasConstant = v, true
+		s.deep = s.deep || isContainer(v)
+	case "properties":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return invalid(ptr, "properties must be an object")
+		}
+		s.properties = make(map[string]*Schema, len(m))
+		for name, sub := range m {
+			if s.properties[name], err = compile(sub, ptr+"/"+pointerEscaper.Replace(name)); err != nil {
+				return err
+			}
+		}
+	case "patternProperties":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return invalid(ptr, "patternProperties must be an object")
+		}
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			re, err := regexp.Compile(name)
+			if err != nil {
+				return invalid(ptr, "invalid pattern %q", name)
+			}
+			sub, err := compile(m[name], ptr+"/"+pointerEscaper.Replace(name))
+			if err != nil {
+				return err
+			}
+			s.patternProperties = append(s.patternProperties, patternSchema{re: re, schema: sub})
+		}
+	case "additionalProperties":
+		s.additionalProperties, err = compile(v, ptr)
+	case "required":
+		list, ok := v.([]interface{})
+		if !ok {
+			return invalid(ptr, "required must be an array of strings")
+		}
+		for _, name := range list {
+			name, ok := name.(string)
+			if !ok {
+				return invalid(ptr, "required must be an array of strings")
+			}
+			s.required = append(s.required, name)
+		}
+	case "prefixItems":
+		list, ok := v.([]interface{})
+		if !ok {
+			return invalid(ptr, "prefixItems must be an array")
+		}
+		for i, sub := range list {
+			item, err := compile(sub, ptr+"/"+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+			s.prefixItems = append(s.prefixItems, item)
+		}
+	case "items":
+		if _, ok := v.([]interface{}); ok {
+			return invalid(ptr, "items must be a schema; use prefixItems for tuples")
+		}
+		s.items, err = compile(v, ptr)
+	case "minimum":
+		s.minimum, err = compileNumber(v, ptr)
+	case "maximum":
+		s.maximum, err = compileNumber(v, ptr)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = compileNumber(v, ptr)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = compileNumber(v, ptr)
+	case "minLength":
+		s.minLength, err = compileCount(v, ptr)
+	case "maxLength":
+		s.maxLength, err = compileCount(v, ptr)
+	case "minItems":
+		s.minItems, err = compileCount(v, ptr)
+	case "maxItems":
+		s.maxItems, err = compileCount(v, ptr)
+	case "minProperties":
+		s.minProperties, err = compileCount(v, ptr)
+	case "maxProperties":
+		s.maxProperties, err = compileCount(v, ptr)
+	case "pattern":
+		p, ok := v.(string)
+		if !ok {
+			return invalid(ptr, "pattern must be a string")
+		}
+		if s.pattern, err = regexp.Compile(p); err != nil {
+			return invalid(ptr, "invalid pattern %q", p)
+		}
+	}
+	return err
+}
+
+// isContainer tells whether the decoded value v is an object or array
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+var typeNames = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "string": true, "integer": true,
+}
+
+func compileTypes(v interface{}, ptr string) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+
+	var types []string
+	for _, t := range list {
+		name, ok := t.(string)
+		if !ok || !typeNames[name] {
+			return nil, invalid(ptr, "invalid type %v", t)
+		}
+		types = append(types, name)
+	}
+	return types, nil
+}
+
+func compileNumber(v interface{}, ptr string) (*float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, invalid(ptr, "must be a number")
+	}
+	return &f, nil
+}
+
+func compileCount(v interface{}, ptr string) (int, error) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) || f > math.MaxInt32 {
+		return 0, invalid(ptr, "must be a non-negative integer")
+	}
+	return int(f), nil
+}
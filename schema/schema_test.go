@@ -0,0 +1,184 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sheenobu/sjson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const orderSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "order",
+	"type": "object",
+	"required": ["id", "lines"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"status": {"enum": ["open", "closed"]},
+		"lines": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"properties": {
+					"sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+					"qty": {"type": "integer", "exclusiveMinimum": 0, "maximum": 100}
+				},
+				"additionalProperties": false
+			}
+		},
+		"point": {"prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+		"tags": {"type": "object", "patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": {"type": "boolean"}},
+		"shape": {"enum": [{"kind": "circle"}, [1, 2], null]},
+		"note": {"type": ["string", "null"], "maxLength": 5}
+	}
+}`
+
+// violations validates doc and returns the path and keyword of each
+// violation
+func violations(s *Schema, doc string) ([]string, error) {
+	err := s.Validate(sjson.NewDecoder(strings.NewReader(doc)))
+
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		return nil, err
+	}
+
+	var out []string
+	for _, v := range ve.Violations {
+		out = append(out, v.Path+" "+v.Keyword)
+	}
+	return out, nil
+}
+
+func TestSchema(t *testing.T) {
+
+	s := MustCompile([]byte(orderSchema))
+
+	Convey("Valid documents should pass", t, func() {
+		doc := `{"id": 7, "status": "open", "lines": [{"sku": "ABC-1", "qty": 2}, {"sku": "XYZ-22"}],
+			"point": [1.5, 2], "tags": {"x-a": "b", "urgent": true}, "shape": {"kind": "circle"}, "note": null, "extra": [1, {"a": 2}]}`
+		So(s.Validate(sjson.NewDecoder(strings.NewReader(doc))), ShouldBeNil)
+	})
+
+	Convey("Every violation should be reported with its path", t, func() {
+		doc := `{"id": 1.5, "status": "lost", "lines": [{"sku": "abc", "qty": 0, "price": 3}, {"qty": 101}, "x"],
+			"point": [1, "2", 3], "tags": {"x-a": 1, "urgent": "yes"}, "shape": {"kind": "square"}, "note": "too long"}`
+		got, err := violations(s, doc)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{
+			"/id type",
+			"/status enum",
+			"/lines/0/sku pattern",
+			"/lines/0/qty exclusiveMinimum",
+			"/lines/0/price additionalProperties",
+			"/lines/1/qty maximum",
+			"/lines/2 type",
+			"/point/1 type",
+			"/point/2 false",
+			"/tags/x-a type",
+			"/tags/urgent type",
+			"/shape enum",
+			"/note maxLength",
+		})
+
+		got, err = violations(s, `{"lines": []}`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"/lines minItems", " required"})
+
+		got, err = violations(s, `[1]`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{" type"})
+	})
+
+	Convey("Violations should carry positions and messages", t, func() {
+		doc := `{"id": 0, "lines": [{}]}`
+		err := s.Validate(sjson.NewDecoder(strings.NewReader(doc)))
+
+		var v *Violation
+		So(errors.As(err, &v), ShouldBeTrue)
+		So(v.Keyword, ShouldEqual, "minimum")
+		So(v.Position.Offset, ShouldEqual, 7)
+		So(err.Error(), ShouldEqual, "0 is less than the minimum 1 at 1:8 (offset 7) in /id")
+	})
+
+	Convey("Syntax errors should be returned as they are", t, func() {
+		_, err := violations(s, `{"id": 1, "lines": [}`)
+		So(errors.Is(err, sjson.ErrSyntax), ShouldBeTrue)
+
+		// parts of the value the schema does not constrain are read too
+		for _, doc := range []string{`{"x": [1,, 2 zzz]}`, `{"x": {"a" 1}}`, `{"x": [tru]}`} {
+			_, err = violations(MustCompile([]byte(`{"type": "object"}`)), doc)
+			So(errors.Is(err, sjson.ErrSyntax), ShouldBeTrue)
+		}
+	})
+
+	Convey("Boolean and empty schemas should accept or reject everything", t, func() {
+		So(MustCompile([]byte(`true`)).Validate(sjson.NewDecoder(strings.NewReader(`{"a": [1]}`))), ShouldBeNil)
+		So(MustCompile([]byte(`{}`)).Validate(sjson.NewDecoder(strings.NewReader(`"x"`))), ShouldBeNil)
+
+		got, err := violations(MustCompile([]byte(`false`)), `1`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{" false"})
+	})
+
+	Convey("Counts and const should be checked", t, func() {
+		c := MustCompile([]byte(`{"minProperties": 2, "maxProperties": 2, "properties": {"v": {"const": [1, {"a": true}]}, "s": {"minLength": 2}}}`))
+
+		got, err := violations(c, `{"v": [1, {"a": true}], "s": "ab"}`)
+		So(err, ShouldBeNil)
+		So(got, ShouldBeEmpty)
+
+		got, err = violations(c, `{"v": [1, {"a": false}], "s": "é", "t": 1}`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{"/v const", "/s minLength", " maxProperties"})
+	})
+
+	Convey("Const and enum should both be checked", t, func() {
+		c := MustCompile([]byte(`{"const": "a", "enum": ["a", "b"]}`))
+
+		got, err := violations(c, `"a"`)
+		So(err, ShouldBeNil)
+		So(got, ShouldBeEmpty)
+
+		got, err = violations(c, `"b"`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{" const"})
+
+		got, err = violations(c, `"c"`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{" const", " enum"})
+
+		c = MustCompile([]byte(`{"const": [1], "enum": [[1], 2]}`))
+		got, err = violations(c, `[1]`)
+		So(err, ShouldBeNil)
+		So(got, ShouldBeEmpty)
+	})
+
+	Convey("Numbers out of the float64 range should be checked, not fail", t, func() {
+		c := MustCompile([]byte(`{"type": "number", "maximum": 5}`))
+
+		got, err := violations(c, `1e400`)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []string{" maximum"})
+
+		got, err = violations(c, `-1e400`)
+		So(err, ShouldBeNil)
+		So(got, ShouldBeEmpty)
+	})
+
+	Convey("Invalid or unsupported schemas should not compile", t, func() {
+		for _, doc := range []string{
+			`1`, `{"type": "float"}`, `{"required": [1]}`, `{"minLength": -1}`, `{"items": [{}]}`,
+			`{"properties": {"a": {"$ref": "#/$defs/a"}}}`, `{"anyOf": [{}]}`, `{"pattern": "("}`,
+		} {
+			_, err := Compile([]byte(doc))
+			So(errors.Is(err, ErrInvalidSchema), ShouldBeTrue)
+		}
+
+		_, err := Compile([]byte(`{"type": }`))
+		So(errors.Is(err, sjson.ErrSyntax), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,438 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sheenobu/sjson"
+)
+
+// A Violation is a value that does not conform to the schema
+type Violation struct {
+	// Keyword is the schema keyword that is not satisfied, such as
+	// "required"
+	Keyword string
+	Msg     string
+
+	// Path is the JSON Pointer of the value, and Position where it
+	// begins in the input
+	Path     string
+	Position sjson.Position
+}
+
+func (v *Violation) Error() string {
+	if v.Path == "" {
+		return fmt.Sprintf("%s at %s", v.Msg, v.Position)
+	}
+	return fmt.Sprintf("%s at %s in %s", v.Msg, v.Position, v.Path)
+}
+
+// A ValidationError lists every violation found in a value, in the
+// order they were found
+type ValidationError struct {
+	Violations []*Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return e.Violations[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more violations)", e.Violations[0], len(e.Violations)-1)
+}
+
+// Unwrap returns the violations
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i, v := range e.Violations {
+		errs[i] = v
+	}
+	return errs
+}
+
+// Validate reads the next value from dec and checks it against the
+// schema. See ValidateToken.
+func (s *Schema) Validate(dec *sjson.Decoder) error {
+	tok, err := next(dec)
+	if err != nil {
+		return err
+	}
+	return s.ValidateToken(tok)
+}
+
+// ValidateToken checks the value of tok against the schema, reading
+// objects and arrays to their end. Values are checked as they are read
+// and only kept in memory when they must be compared with an enum or
+// const that includes objects or arrays. The parts of the value the
+// schema does not constrain are read all the same, so the whole input
+// must be well-formed.
+//
+// If the value does not conform to the schema, the error is a
+// *ValidationError listing every violation. Errors reading the input
+// are returned as they are.
+func (s *Schema) ValidateToken(tok sjson.Token) error {
+	if mt, ok := tok.(*sjson.MemberToken); ok {
+		tok = mt.Value
+	}
+
+	v := &validator{}
+	if _, err := v.value(tok, []*Schema{s}, false); err != nil {
+		return err
+	}
+
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+// next returns the next token of ct that is not a comment
+func next(ct sjson.ComplexToken) (sjson.Token, error) {
+	for {
+		tok, err := ct.Next()
+		if err != nil || tok.Type() != sjson.CommentType {
+			return tok, err
+		}
+	}
+}
+
+// walk reads the rest of ct token by token, which unlike Skip checks
+// that it is well-formed
+func walk(ct sjson.ComplexToken) error {
+	for {
+		tok, err := next(ct)
+		if err != nil {
+			return err
+		}
+		if tok.Type() == sjson.EndType {
+			return nil
+		}
+
+		if mt, ok := tok.(*sjson.MemberToken); ok {
+			tok = mt.Value
+		}
+		if c, ok := tok.(sjson.ComplexToken); ok {
+			if err := walk(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type validator struct {
+	violations []*Violation
+}
+
+func (v *validator) report(tok sjson.Token, keyword, format string, args ...interface{}) {
+	v.violations = append(v.violations, &Violation{
+		Keyword:  keyword,
+		Msg:      fmt.Sprintf(format, args...),
+		Path:     tok.Pointer(),
+		Position: tok.Position(),
+	})
+}
+
+// value checks tok against every schema in schemas. With build set, the
+// value is decoded and returned.
+func (v *validator) value(tok sjson.Token, schemas []*Schema, build bool) (interface{}, error) {
+	var active []*Schema
+	for _, s := range schemas {
+		if s.never {
+			v.report(tok, "false", "no value is allowed here")
+		} else if !s.empty() {
+			active = append(active, s)
+		}
+		build = build || s.deep
+	}
+
+	typ := tok.Type()
+	if typ != sjson.ObjectType && typ != sjson.ArrayType {
+		val, err := scalar(tok)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range active {
+			v.checkType(tok, s, val)
+			v.checkScalar(tok, s, val)
+			v.checkEnum(tok, s, val)
+		}
+		return val, nil
+	}
+
+	if len(active) == 0 && !build {
+		return nil, walk(tok.(sjson.ComplexToken))
+	}
+
+	for _, s := range active {
+		v.checkType(tok, s, nil)
+	}
+
+	var val interface{}
+	var err error
+	if typ == sjson.ObjectType {
+		val, err = v.object(tok, active, build)
+	} else {
+		val, err = v.array(tok, active, build)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range active {
+		v.checkEnum(tok, s, val)
+	}
+	return val, nil
+}
+
+// scalar decodes the value of the simple token tok. A number too large
+// for a float64 is still valid JSON, and is checked as ±Inf.
+func scalar(tok sjson.Token) (interface{}, error) {
+	var val interface{}
+	err := sjson.DecodeToken(tok, &val)
+	if err == nil || tok.Type() != sjson.NumberType {
+		return val, err
+	}
+
+	f, perr := strconv.ParseFloat(fmt.Sprint(tok), 64)
+	if !errors.Is(perr, strconv.ErrRange) {
+		return nil, err
+	}
+	return f, nil
+}
+
+// object checks the members of tok
+func (v *validator) object(tok sjson.Token, schemas []*Schema, build bool) (interface{}, error) {
+	ct := tok.(sjson.ComplexToken)
+
+	var m map[string]interface{}
+	if build {
+		m = make(map[string]interface{})
+	}
+	seen := make(map[string]bool)
+
+	n := 0
+	for {
+		t, err := next(ct)
+		if err != nil {
+			return nil, err
+		}
+		if t.Type() == sjson.EndType {
+			break
+		}
+
+		mt := t.(*sjson.MemberToken)
+		n++
+		seen[mt.Key] = true
+
+		var subs []*Schema
+		for _, s := range schemas {
+			matched := false
+			if sub, ok := s.properties[mt.Key]; ok {
+				subs = append(subs, sub)
+				matched = true
+			}
+			for _, pp := range s.patternProperties {
+				if pp.re.MatchString(mt.Key) {
+					subs = append(subs, pp.schema)
+					matched = true
+				}
+			}
+
+			switch add := s.additionalProperties; {
+			case matched || add == nil:
+			case add.never:
+				v.report(mt, "additionalProperties", "property %q is not allowed", mt.Key)
+			default:
+				subs = append(subs, add)
+			}
+		}
+
+		val, err := v.value(mt.Value, subs, build)
+		if err != nil {
+			return nil, err
+		}
+		if build {
+			m[mt.Key] = val
+		}
+	}
+
+	for _, s := range schemas {
+		for _, name := range s.required {
+			if !seen[name] {
+				v.report(tok, "required", "missing property %q", name)
+			}
+		}
+		if s.minProperties >= 0 && n < s.minProperties {
+			v.report(tok, "minProperties", "object has %d properties, fewer than %d", n, s.minProperties)
+		}
+		if s.maxProperties >= 0 && n > s.maxProperties {
+			v.report(tok, "maxProperties", "object has %d properties, more than %d", n, s.maxProperties)
+		}
+	}
+
+	if !build {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// array checks the elements of tok
+func (v *validator) array(tok sjson.Token, schemas []*Schema, build bool) (interface{}, error) {
+	ct := tok.(sjson.ComplexToken)
+
+	var a []interface{}
+	if build {
+		a = make([]interface{}, 0)
+	}
+
+	n := 0
+	for ; ; n++ {
+		t, err := next(ct)
+		if err != nil {
+			return nil, err
+		}
+		if t.Type() == sjson.EndType {
+			break
+		}
+
+		var subs []*Schema
+		for _, s := range schemas {
+			if n < len(s.prefixItems) {
+				subs = append(subs, s.prefixItems[n])
+			} else if s.items != nil {
+				subs = append(subs, s.items)
+			}
+		}
+
+		val, err := v.value(t, subs, build)
+		if err != nil {
+			return nil, err
+		}
+		if build {
+			a = append(a, val)
+		}
+	}
+
+	for _, s := range schemas {
+		if s.minItems >= 0 && n < s.minItems {
+			v.report(tok, "minItems", "array has %d items, fewer than %d", n, s.minItems)
+		}
+		if s.maxItems >= 0 && n > s.maxItems {
+			v.report(tok, "maxItems", "array has %d items, more than %d", n, s.maxItems)
+		}
+	}
+
+	if !build {
+		return nil, nil
+	}
+	return a, nil
+}
+
+func (v *validator) checkType(tok sjson.Token, s *Schema, val interface{}) {
+	if len(s.types) == 0 {
+		return
+	}
+
+	for _, name := range s.types {
+		if typeMatches(name, tok.Type(), val) {
+			return
+		}
+	}
+	v.report(tok, "type", "expected %s, found %s", strings.Join(s.types, " or "), typeName(tok.Type()))
+}
+
+func typeMatches(name string, typ sjson.Type, val interface{}) bool {
+	switch name {
+	case "integer":
+		f, ok := val.(float64)
+		return typ == sjson.NumberType && ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	default:
+		return name == typeName(typ)
+	}
+}
+
+func typeName(typ sjson.Type) string {
+	switch typ {
+	case sjson.NullType:
+		return "null"
+	case sjson.BoolType:
+		return "boolean"
+	case sjson.ObjectType:
+		return "object"
+	case sjson.ArrayType:
+		return "array"
+	case sjson.NumberType:
+		return "number"
+	case sjson.StringType:
+		return "string"
+	}
+	return typ.String()
+}
+
+// checkScalar checks the keywords that apply to strings and numbers
+func (v *validator) checkScalar(tok sjson.Token, s *Schema, val interface{}) {
+	switch val := val.(type) {
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.minLength >= 0 && n < s.minLength {
+			v.report(tok, "minLength", "string has %d characters, fewer than %d", n, s.minLength)
+		}
+		if s.maxLength >= 0 && n > s.maxLength {
+			v.report(tok, "maxLength", "string has %d characters, more than %d", n, s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			v.report(tok, "pattern", "string does not match pattern %q", s.pattern)
+		}
+	case float64:
+		if s.minimum != nil && val < *s.minimum {
+			v.report(tok, "minimum", "%v is less than the minimum %v", val, *s.minimum)
+		}
+		if s.maximum != nil && val > *s.maximum {
+			v.report(tok, "maximum", "%v is greater than the maximum %v", val, *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && val <= *s.exclusiveMinimum {
+			v.report(tok, "exclusiveMinimum", "%v is not greater than %v", val, *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && val >= *s.exclusiveMaximum {
+			v.report(tok, "exclusiveMaximum", "%v is not less than %v", val, *s.exclusiveMaximum)
+		}
+	}
+}
+
+// checkEnum checks enum and const. Objects and arrays are only decoded
+// if they may be equal to one of the values.
+func (v *validator) checkEnum(tok sjson.Token, s *Schema, val interface{}) {
+	typ := tok.Type()
+	decoded := s.deep || (typ != sjson.ObjectType && typ != sjson.ArrayType)
+
+	if s.hasConstant && !(decoded && reflect.DeepEqual(val, s.constant)) {
+		v.report(tok, "const", "value does not equal the const value")
+	}
+	if s.hasEnum && !(decoded && oneOf(val, s.enum)) {
+		v.report(tok, "enum", "value is not one of the enum values")
+	}
+}
+
+// oneOf tells whether val equals one of values
+func oneOf(val interface{}, values []interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(val, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// empty tells whether the schema accepts any value
+func (s *Schema) empty() bool {
+	return !s.never && s.types == nil && !s.hasEnum && !s.hasConstant &&
+		s.properties == nil && s.patternProperties == nil && s.additionalProperties == nil && s.required == nil &&
+		s.prefixItems == nil && s.items == nil &&
+		s.minimum == nil && s.maximum == nil && s.exclusiveMinimum == nil && s.exclusiveMaximum == nil &&
+		s.minLength < 0 && s.maxLength < 0 && s.minItems < 0 && s.maxItems < 0 &&
+		s.minProperties < 0 && s.maxProperties < 0 && s.pattern == nil
+}
@@ -0,0 +1,107 @@
+package sjson
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// Find reads the value at the JSON Pointer (RFC 6901) pointer from r.
+// See Decoder.Seek.
+func Find(r io.Reader, pointer string) (Token, error) {
+	return NewDecoder(r).Seek(pointer)
+}
+
+// Seek reads the next value and descends to the value at the JSON
+// Pointer (RFC 6901) pointer within it, skipping the members and
+// elements off the path without decoding them. It returns as soon as
+// the target value begins, so an object or array is returned unread and
+// the rest of the enclosing value is left in the input; the decoder
+// itself should not be used after that.
+func (dec *Decoder) Seek(pointer string) (Token, error) {
+	refs, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err := nextValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if tok, err = seekChild(tok, ref); err != nil {
+			return nil, err
+		}
+		if tok == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, pointer)
+		}
+	}
+	return tok, nil
+}
+
+// seekChild returns the member or element ref of tok, or nil if there
+// is none
+func seekChild(tok Token, ref string) (Token, error) {
+	switch t := tok.(type) {
+	case objectToken:
+		t.want = func(n *pathNode) bool {
+			return n.key == ref
+		}
+	case arrayToken:
+		i, ok := arrayIndex(ref)
+		if !ok {
+			return nil, nil
+		}
+		t.want = func(n *pathNode) bool {
+			return n.index == i
+		}
+	default:
+		return nil, nil
+	}
+
+	child, err := nextValue(tok.(ComplexToken))
+	if err != nil || child.Type() == EndType {
+		return nil, err
+	}
+	if mt, ok := child.(*MemberToken); ok {
+		return mt.Value, nil
+	}
+	return child, nil
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference
+// tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: %q does not begin with '/'", ErrInvalidPointer, pointer)
+	}
+
+	refs := strings.Split(pointer[1:], "/")
+	for i, ref := range refs {
+		for j := 0; j < len(ref); j++ {
+			if ref[j] == '~' && (j+1 == len(ref) || (ref[j+1] != '0' && ref[j+1] != '1')) {
+				return nil, fmt.Errorf("%w: %q has an invalid escape", ErrInvalidPointer, pointer)
+			}
+		}
+		refs[i] = pointerUnescaper.Replace(ref)
+	}
+	return refs, nil
+}
+
+// arrayIndex parses an array index, which has no sign or leading zeros.
+// The index "-" never exists.
+func arrayIndex(ref string) (int, bool) {
+	if ref == "" || (len(ref) > 1 && ref[0] == '0') || ref[0] < '0' || ref[0] > '9' {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(ref)
+	return i, err == nil
+}
@@ -0,0 +1,69 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSeek(t *testing.T) {
+
+	const doc = `{"a": {"skip": [1, {"x": "}"}], "b": [10, [20, 21], {"c~d": 3, "e/f": [4]}]}, "": 5, "z": 6}`
+
+	Convey("Find should return the value at a pointer", t, func() {
+		cases := map[string]string{
+			"/a/b/0":        "10",
+			"/a/b/1/1":      "21",
+			"/a/b/2/c~0d":   "3",
+			"/a/b/2/e~1f/0": "4",
+			"/":             "5",
+			"/z":            "6",
+		}
+		for pointer, want := range cases {
+			t, err := Find(strings.NewReader(doc), pointer)
+			So(err, ShouldBeNil)
+			So(toText(t), ShouldEqual, want)
+			So(t.Pointer(), ShouldEqual, pointer)
+		}
+	})
+
+	Convey("Find should stop at the target value", t, func() {
+		r := strings.NewReader(doc)
+		t, err := Find(r, "/a/b/1")
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ArrayType)
+		So(t.Position().Offset, ShouldEqual, strings.Index(doc, "[20"))
+
+		var v []int
+		So(DecodeToken(t, &v), ShouldBeNil)
+		So(v, ShouldResemble, []int{20, 21})
+	})
+
+	Convey("The empty pointer should return the whole document", t, func() {
+		t, err := Find(strings.NewReader(doc), "")
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, ObjectType)
+	})
+
+	Convey("Missing values should not be found", t, func() {
+		for _, pointer := range []string{"/q", "/a/b/3", "/a/b/-", "/a/b/01", "/a/b/x", "/z/0", "/a/skip/1/x/y"} {
+			_, err := Find(strings.NewReader(doc), pointer)
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+		}
+	})
+
+	Convey("Invalid pointers should be rejected", t, func() {
+		for _, pointer := range []string{"a", "/a~", "/a~2"} {
+			_, err := Find(strings.NewReader(doc), pointer)
+			So(errors.Is(err, ErrInvalidPointer), ShouldBeTrue)
+		}
+	})
+
+	Convey("Truncated input in skipped values should still be reported", t, func() {
+		_, err := Find(strings.NewReader(`{"a": [1, 2, "x], "b": 1}`), "/b")
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,115 @@
+package sjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// recordSeparator begins each record of a JSON text sequence
+const recordSeparator = 0x1E
+
+// A RecordError reports a record of a JSON text sequence that was
+// dropped because it was truncated or invalid
+type RecordError struct {
+	Record int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d dropped: %v", e.Record, e.Err)
+}
+
+// Unwrap returns the error that caused the record to be dropped
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// nextRecord returns the value of the next record of a sequence.
+// Records that fail before their value is returned are dropped here;
+// errors inside an object or array are returned by that token, and the
+// record is dropped when Next is called again.
+func (dec *Decoder) nextRecord() (Token, error) {
+	for {
+		if err := dec.seekRecord(); err != nil {
+			return nil, err
+		}
+
+		tok, err := dec.next()
+		if err == nil || !damaged(err) {
+			return tok, err
+		}
+	}
+}
+
+// damaged tells whether err is caused by the content of a record, as
+// opposed to the underlying reader or a limit
+func damaged(err error) bool {
+	return errors.Is(err, ErrSyntax) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// seekRecord reports the current record if it was dropped, and skips
+// to the start of the next one
+func (dec *Decoder) seekRecord() error {
+	r := dec.r
+
+	if r.recordErr != nil && r.opts.OnDroppedRecord != nil {
+		r.opts.OnDroppedRecord(&RecordError{Record: r.record, Err: r.recordErr})
+	}
+	r.recordErr = nil
+
+	// the rest of the current record, if any, is not read
+	for {
+		d, err := r.window()
+		if err != nil {
+			return err
+		}
+
+		i := bytes.IndexByte(d, recordSeparator)
+		if i < 0 {
+			r.Discard(len(d))
+			continue
+		}
+		r.Discard(i + 1)
+
+		// consecutive separators do not denote empty records
+		if d, err := r.Peek(1); err != nil || d[0] != recordSeparator {
+			break
+		}
+	}
+
+	r.record++
+	r.level = 0
+	return nil
+}
+
+// endOfRecord fails on the record separator just consumed while
+// skipping a value, leaving it for the next record
+func (r *reader) endOfRecord() error {
+	r.off--
+	return r.eofError("Unexpected end of record")
+}
+
+// endRecord checks that the value just read ends its record. Numbers
+// and literal names must be followed by whitespace, or they may have
+// been truncated.
+func (f *frame) endRecord() error {
+	start := f.r.offset()
+
+	if err := f.r.skipSpace(); err != nil {
+		if err != io.EOF {
+			return err
+		}
+	} else if d, _ := f.r.Peek(1); d[0] != recordSeparator {
+		return f.r.unexpected(d, "record separator")
+	}
+
+	switch f.tok.Type() {
+	case NumberType, BoolType, NullType:
+		if f.r.offset() == start {
+			return f.r.eofError("Unexpected end of record after " + toText(f.tok))
+		}
+	}
+	return nil
+}
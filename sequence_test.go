@@ -0,0 +1,136 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSequence(t *testing.T) {
+
+	const rs = "\x1e"
+
+	Convey("Sequence mode should read each record", t, func() {
+		in := rs + `{"a": 1}` + "\n" + rs + rs + `[2]` + "\n" + rs + ` "three"` + "\n" + rs + "4\n"
+		dec := NewDecoderWithOptions(strings.NewReader(in), DecoderOptions{Mode: Sequence})
+
+		var types []Type
+		for t, err := range dec.All() {
+			So(err, ShouldBeNil)
+			types = append(types, t.Type())
+		}
+		So(types, ShouldResemble, []Type{ObjectType, ArrayType, StringType, NumberType})
+	})
+
+	Convey("Truncated records should be dropped and reported", t, func() {
+		in := rs + `{"a": [1, 2` + rs + `{"b": 2}` + "\n" +
+			rs + `12` + rs + `true` + "\n" +
+			rs + `"cut` + rs + `null` + "\n" +
+			rs + `{"c": 3} 4` + "\n" +
+			rs + `[5]` + "\n" +
+			rs + `6`
+
+		var dropped []int
+		opts := DecoderOptions{
+			Mode: Sequence,
+			OnDroppedRecord: func(err *RecordError) {
+				dropped = append(dropped, err.Record)
+			},
+		}
+		dec := NewDecoderWithOptions(strings.NewReader(in), opts)
+
+		var got []string
+		var errs int
+		for {
+			t, err := dec.Next()
+			if err == io.EOF {
+				break
+			}
+			So(err, ShouldBeNil)
+
+			if ct, ok := t.(ComplexToken); ok {
+				raw := ""
+				if err := drain(ct); err != nil {
+					So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+					errs++
+					raw = "error"
+				}
+				got = append(got, t.Type().String()+raw)
+				continue
+			}
+			got = append(got, toText(t))
+		}
+
+		So(got, ShouldResemble, []string{"ObjectTypeerror", "ObjectType", "true", "null", "ObjectTypeerror", "ArrayType"})
+		So(errs, ShouldEqual, 2)
+		So(dropped, ShouldResemble, []int{1, 3, 5, 7, 9})
+	})
+
+	Convey("Skipping a truncated record should stop at the next record", t, func() {
+		var dropped []int
+		opts := DecoderOptions{
+			Mode: Sequence,
+			OnDroppedRecord: func(err *RecordError) {
+				dropped = append(dropped, err.Record)
+			},
+		}
+
+		in := rs + `{"a":1}` + "\n" + rs + `{"x":{"y":1,` + "\n" + rs + `{"a":3}` + "\n" + rs + `{"a":4}` + "\n"
+		dec := NewDecoderWithOptions(strings.NewReader(in), opts)
+
+		var got []int
+		for {
+			var v struct{ A int }
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+				continue
+			}
+			got = append(got, v.A)
+		}
+		So(got, ShouldResemble, []int{1, 3, 4})
+		So(dropped, ShouldResemble, []int{2})
+
+		dropped = nil
+		in = rs + `[1,[2,"a` + "\n" + rs + `[3]` + "\n" + rs + `[4]` + "\n"
+		dec = NewDecoderWithOptions(strings.NewReader(in), opts)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(errors.Is(t.(ComplexToken).Skip(), io.ErrUnexpectedEOF), ShouldBeTrue)
+
+		t, err = dec.Next()
+		So(err, ShouldBeNil)
+		raw, err := t.(ComplexToken).Raw()
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `[3]`)
+		So(dropped, ShouldResemble, []int{1})
+
+		dropped = nil
+		in = rs + `[1,[2,` + "\n" + rs + `[3]` + "\n" + rs + `[4]` + "\n"
+		dec = NewDecoderWithOptions(strings.NewReader(in), opts)
+
+		var n int
+		for t, err := range dec.All() {
+			So(err, ShouldBeNil)
+			So(t.Type(), ShouldEqual, ArrayType)
+			n++
+		}
+		So(n, ShouldEqual, 3)
+		So(dropped, ShouldResemble, []int{1})
+
+		dropped = nil
+		dec = NewDecoderWithOptions(strings.NewReader(rs+`[1, "a`+rs+"2\n"), opts)
+		So(errors.Is(dec.Skip(), io.ErrUnexpectedEOF), ShouldBeTrue)
+		t, err = dec.Next()
+		So(err, ShouldBeNil)
+		So(toText(t), ShouldEqual, "2")
+		So(dropped, ShouldResemble, []int{1})
+	})
+}
@@ -0,0 +1,297 @@
+package sjson
+
+import "io"
+
+// skipWhitespace consumes whitespace before a value, and comments in
+// the JSONC and JSON5 syntaxes
+func (r *reader) skipWhitespace() error {
+	for {
+		if err := r.skipSpace(); err != nil {
+			return err
+		}
+
+		d, _ := r.Peek(1)
+		if d[0] != '/' || r.opts.Syntax == JSON {
+			return nil
+		}
+		r.Discard(1)
+		if err := r.skipComment(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipValue consumes the next complete value without decoding it.
+// Strings and the contents of objects and arrays are only scanned, but
+// numbers and literal names are checked like when decoding them.
+func (r *reader) skipValue() error {
+	if err := r.skipWhitespace(); err != nil {
+		return err
+	}
+
+	d, _ := r.Peek(1)
+	json5 := r.opts.Syntax == JSON5
+
+	switch {
+	case d[0] == '{' || d[0] == '[':
+		level := r.level
+		r.Discard(1)
+		r.level++
+		return r.skipNested(level, closerOf(d[0]))
+	case d[0] == '"' || (json5 && d[0] == '\''):
+		quote := d[0]
+		r.Discard(1)
+		return r.skipString(quote)
+	case d[0] == '-' || (d[0] >= '0' && d[0] <= '9'):
+		var err error
+		if json5 {
+			_, _, err = r.scanNumber5()
+		} else {
+			_, _, err = r.scanNumber()
+		}
+		return err
+	case json5 && (d[0] == '+' || d[0] == '.' || d[0] == 'I' || d[0] == 'N'):
+		_, _, err := r.scanNumber5()
+		return err
+	case d[0] == 't':
+		return r.scanLiteral("true")
+	case d[0] == 'f':
+		return r.scanLiteral("false")
+	case d[0] == 'n':
+		return r.scanLiteral("null")
+	}
+	return r.unexpected(d, "value")
+}
+
+// skipTo consumes input until the objects and arrays open above level
+// have all been closed. Brackets inside strings are ignored.
+func (r *reader) skipTo(level int) error {
+	return r.skipNested(level, 0)
+}
+
+// skipNested is skipTo for when the innermost open object or array was
+// opened by the caller rather than by a frame, closed by closer. The
+// closing brackets must match the opening ones.
+func (r *reader) skipNested(level int, closer byte) error {
+	// the closing brackets of the objects and arrays opened here
+	var stack [32]byte
+	open := stack[:0]
+	if closer != 0 {
+		open = append(open, closer)
+	}
+
+	for r.level > level {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return r.eofError("Unexpected end of input")
+		}
+		if err != nil {
+			return err
+		}
+
+		if b == recordSeparator && r.opts.Mode == Sequence {
+			return r.endOfRecord()
+		}
+
+		switch b {
+		case '{', '[':
+			open = append(open, closerOf(b))
+			r.level++
+		case '}', ']':
+			want := r.closerAt(r.level)
+			if len(open) > 0 {
+				want = open[len(open)-1]
+				open = open[:len(open)-1]
+			}
+			if want != 0 && b != want {
+				r.off--
+				return r.unexpected([]byte{b}, "'"+string(want)+"'")
+			}
+			r.level--
+		case '"':
+			if err := r.skipString('"'); err != nil {
+				return err
+			}
+		case '\'':
+			if r.opts.Syntax != JSON5 {
+				continue
+			}
+			if err := r.skipString('\''); err != nil {
+				return err
+			}
+		case '/':
+			if r.opts.Syntax == JSON {
+				continue
+			}
+			if err := r.skipComment(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// closerOf returns the closing bracket for the opening bracket b
+func closerOf(b byte) byte {
+	if b == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+// closerAt returns the closing bracket of the object or array read by
+// the frame at level, or 0 if there is none
+func (r *reader) closerAt(level int) byte {
+	if level >= len(r.frames) || r.frames[level] == nil {
+		return 0
+	}
+	if r.frames[level].array {
+		return ']'
+	}
+	return '}'
+}
+
+// skipString consumes the rest of a string whose opening quote has
+// already been consumed
+func (r *reader) skipString(quote byte) error {
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return r.eofError("Unexpected end of input in string")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case b == quote:
+			return nil
+		case b == recordSeparator && r.opts.Mode == Sequence:
+			return r.endOfRecord()
+		case b == '\\':
+			b, err := r.ReadByte()
+			if err != nil {
+				return r.eofError("Unexpected end of input in string")
+			}
+			if b == recordSeparator && r.opts.Mode == Sequence {
+				return r.endOfRecord()
+			}
+		}
+	}
+}
+
+// skipComment consumes the rest of a comment whose opening slash has
+// already been consumed
+func (r *reader) skipComment() error {
+	b, err := r.ReadByte()
+	if err == io.EOF {
+		return r.eofError("Unexpected end of input in comment")
+	}
+	if err != nil {
+		return err
+	}
+
+	switch b {
+	case '/':
+		for {
+			d, err := r.Peek(1)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d[0] == '\n' || d[0] == '\r' {
+				return nil
+			}
+			r.Discard(1)
+		}
+	case '*':
+		var prev byte
+		for {
+			b, err := r.ReadByte()
+			if err == io.EOF {
+				return r.eofError("Unexpected end of input in comment")
+			}
+			if err != nil {
+				return err
+			}
+			if prev == '*' && b == '/' {
+				return nil
+			}
+			if b == recordSeparator && r.opts.Mode == Sequence {
+				return r.endOfRecord()
+			}
+			prev = b
+		}
+	}
+
+	return r.unexpected([]byte{b}, "comment")
+}
+
+// captureRaw runs skip while recording the consumed bytes, which are
+// returned after prefix
+func (r *reader) captureRaw(prefix string, skip func() error) ([]byte, error) {
+	m := r.mark()
+	err := skip()
+	d := r.release(m)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 0, len(prefix)+len(d))
+	return append(append(raw, prefix...), d...), nil
+}
+
+// Skip consumes the next value without decoding it, scanning only the
+// brackets and strings of objects and arrays
+func (dec *Decoder) Skip() error {
+	if err := dec.beginValue(); err != nil {
+		return err
+	}
+	if err := dec.r.skipValue(); err != nil {
+		return dec.annotate(err)
+	}
+
+	dec.values++
+	return nil
+}
+
+// Raw consumes the next value and returns its exact bytes, without
+// surrounding whitespace
+func (dec *Decoder) Raw() ([]byte, error) {
+	if err := dec.beginValue(); err != nil {
+		return nil, err
+	}
+	if err := dec.r.skipWhitespace(); err != nil {
+		return nil, err
+	}
+
+	raw, err := dec.r.captureRaw("", dec.r.skipValue)
+	if err != nil {
+		return nil, dec.annotate(err)
+	}
+
+	dec.values++
+	return raw, nil
+}
+
+// beginValue prepares to skip or capture the next top-level value like
+// Next does before reading it: the rest of a partly read value is
+// skipped, in Strict mode only one value is accepted, and in Sequence
+// mode the value is that of the next record
+func (dec *Decoder) beginValue() error {
+	if dec.r.opts.Mode == Sequence {
+		return dec.seekRecord()
+	}
+	if dec.values > 0 && dec.r.opts.Mode == Strict {
+		return dec.end()
+	}
+	if dec.r.level > dec.depth {
+		if err := dec.skipChildren(); err != nil {
+			return dec.annotate(err)
+		}
+	}
+	return nil
+}
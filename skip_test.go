@@ -0,0 +1,174 @@
+package sjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSkip(t *testing.T) {
+
+	Convey("Skipping an object member should leave the next member readable", t, func() {
+		r := strings.NewReader(`{"big": {"a": [1, "}]", {"b": "\"{"}]}, "want": 2}`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.(*MemberToken).Value.(ComplexToken).Skip(), ShouldBeNil)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.(*MemberToken).Key, ShouldEqual, "want")
+		So(toString(t.(*MemberToken).Value), ShouldEqual, "2")
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, EndType)
+
+		_, err = ct.Next()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Skipping a partially read array should skip the unread elements", t, func() {
+		r := strings.NewReader(`[[1, [2, 3], 4], 5]`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		outer := t.(ComplexToken)
+
+		t, err = outer.Next()
+		So(err, ShouldBeNil)
+		inner := t.(ComplexToken)
+
+		_, err = inner.Next()
+		So(err, ShouldBeNil)
+
+		// leave [2, 3] unread as well
+		_, err = inner.Next()
+		So(err, ShouldBeNil)
+		So(inner.Skip(), ShouldBeNil)
+
+		t, err = outer.Next()
+		So(err, ShouldBeNil)
+		So(toString(t), ShouldEqual, "5")
+	})
+
+	Convey("Decoder.Skip should skip whole top-level values", t, func() {
+		dec := NewDecoder(strings.NewReader(` {"a": [1, 2]} "s\"" 12 true [] "last"`))
+
+		for i := 0; i < 5; i++ {
+			So(dec.Skip(), ShouldBeNil)
+		}
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(toString(t), ShouldEqual, "last")
+
+		So(dec.Skip(), ShouldEqual, io.EOF)
+	})
+
+	Convey("Skipping truncated input should fail with an UnexpectedEOFError", t, func() {
+		dec := NewDecoder(strings.NewReader(`{"a": [1, "x`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		So(errors.Is(t.(ComplexToken).Skip(), io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+
+	Convey("Raw should return the exact bytes of a value", t, func() {
+		r := strings.NewReader(`{"a": {"b" : [1, 2.50, "é"] }, "c": 3}  [ 4 ]`)
+		dec := NewDecoder(r)
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+
+		raw, err := t.(*MemberToken).Value.(ComplexToken).Raw()
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"b" : [1, 2.50, "é"] }`)
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.(*MemberToken).Key, ShouldEqual, "c")
+
+		t, err = ct.Next()
+		So(err, ShouldBeNil)
+		So(t.Type(), ShouldEqual, EndType)
+
+		raw, err = dec.Raw()
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `[ 4 ]`)
+	})
+
+	Convey("Raw should refuse a partially read value", t, func() {
+		dec := NewDecoder(strings.NewReader(`[1, 2]`))
+
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+		ct := t.(ComplexToken)
+
+		_, err = ct.Next()
+		So(err, ShouldBeNil)
+
+		_, err = ct.Raw()
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Mismatched brackets should fail to skip", t, func() {
+		for _, s := range []string{`[1}`, `{]`, `[{"a": 1]]`, `{"a": [1}}`} {
+			dec := NewDecoder(strings.NewReader(s))
+			_, err := dec.Raw()
+			So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+
+			dec = NewDecoder(strings.NewReader(s))
+			So(errors.Is(dec.Skip(), ErrSyntax), ShouldBeTrue)
+		}
+
+		dec := NewDecoder(strings.NewReader(`[1}`))
+		t, err := dec.Next()
+		So(err, ShouldBeNil)
+
+		var se *SyntaxError
+		_, err = t.(ComplexToken).Raw()
+		So(errors.As(err, &se), ShouldBeTrue)
+		So(se.Found, ShouldEqual, "}")
+		So(se.Position.Offset, ShouldEqual, 2)
+	})
+
+	Convey("Skipping should reject invalid numbers and literal names", t, func() {
+		for _, s := range []string{`abc`, `tru`, `nul `, `01`, `-`, `1.`, `'a'`} {
+			dec := NewDecoder(strings.NewReader(s))
+			So(dec.Skip(), ShouldNotBeNil)
+		}
+
+		_, err := Find(strings.NewReader(`{"a": abc, "b": 1}`), "/b")
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+	})
+
+	Convey("Skipped values should count as the only value in Strict mode", t, func() {
+		dec := NewDecoderWithOptions(strings.NewReader(`{"a": 1} {"b": 2}`), DecoderOptions{Mode: Strict})
+		So(dec.Skip(), ShouldBeNil)
+		So(errors.Is(dec.Skip(), ErrSyntax), ShouldBeTrue)
+
+		dec = NewDecoderWithOptions(strings.NewReader(`[1] 2`), DecoderOptions{Mode: Strict})
+		_, err := dec.Raw()
+		So(err, ShouldBeNil)
+		_, err = dec.Next()
+		So(errors.Is(err, ErrSyntax), ShouldBeTrue)
+
+		dec = NewDecoderWithOptions(strings.NewReader(`[1] `), DecoderOptions{Mode: Strict})
+		So(dec.Skip(), ShouldBeNil)
+		So(dec.Skip(), ShouldEqual, io.EOF)
+	})
+}
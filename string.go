@@ -1,21 +1,196 @@
 package sjson
 
 import (
-	"fmt"
+	"bytes"
+	"io"
 	"reflect"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
-type stringToken string
+type stringToken struct {
+	*scalar
+}
 
 func (s stringToken) Type() Type {
 	return StringType
 }
 
 func (s stringToken) Unmarshal(b interface{}) error {
-	reflect.Indirect(reflect.ValueOf(b)).SetString(string(s))
+	v, err := s.unmarshalTarget(b, "string")
+	if err != nil {
+		return err
+	}
+	if ok, err := unmarshaler(s, v); ok {
+		return err
+	}
+	if v.Kind() != reflect.String {
+		return s.typeError("string", v.Type())
+	}
+
+	v.SetString(string(s.text))
 	return nil
 }
 
 func (s stringToken) String() string {
-	return fmt.Sprintf("%s", string(s))
+	return string(s.text)
+}
+
+// readString reads a JSON string body from r, up to and including the
+// closing quote, and returns the unescaped text, which stays valid until
+// the next string is read. The opening quote must already have been
+// consumed. Like scanString, it fails with the text read so far if the
+// string is too long.
+func readString(r *reader, quote byte) ([]byte, error) {
+	str := &r.text
+	str.Reset()
+
+	for {
+		d, err := r.window()
+		if err != nil {
+			if err == io.EOF {
+				return nil, r.eofError("Unexpected end of input in string")
+			}
+			return nil, err
+		}
+
+		// copy the run of bytes up to the next quote, escape or control
+		// character at once
+		i := 0
+		for i < len(d) && d[i] != quote && d[i] != '\\' && d[i] >= 0x20 {
+			i++
+		}
+		str.Write(d[:i])
+		r.Discard(i)
+
+		if max := r.opts.MaxStringLength; max > 0 && str.Len() > max {
+			return str.Bytes(), r.limitError("MaxStringLength", int64(max))
+		}
+		if i == len(d) {
+			continue
+		}
+
+		b := d[i]
+		if b < 0x20 && !(b == '\t' && r.opts.Syntax == JSON5) {
+			return nil, r.unexpected(d[i:i+1], "escaped control character")
+		}
+		r.Discard(1)
+
+		switch {
+		case b == quote:
+			return str.Bytes(), nil
+		case b == '\\':
+			if err := readEscape(r, str); err != nil {
+				return nil, err
+			}
+		default:
+			str.WriteByte(b)
+		}
+
+		if max := r.opts.MaxStringLength; max > 0 && str.Len() > max {
+			return str.Bytes(), r.limitError("MaxStringLength", int64(max))
+		}
+	}
+}
+
+// readEscape decodes the escape sequence following a backslash and
+// writes the result to str.
+func readEscape(r *reader, str *bytes.Buffer) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			return r.eofError("Unexpected end of input in string")
+		}
+		return err
+	}
+
+	switch b {
+	case '"', '\\', '/':
+		str.WriteByte(b)
+	case 'b':
+		str.WriteByte('\b')
+	case 'f':
+		str.WriteByte('\f')
+	case 'n':
+		str.WriteByte('\n')
+	case 'r':
+		str.WriteByte('\r')
+	case 't':
+		str.WriteByte('\t')
+	case 'u':
+		c, err := readHex4(r)
+		if err != nil {
+			return err
+		}
+
+		if utf16.IsSurrogate(c) {
+			c, err = readSurrogate(r, c)
+			if err != nil {
+				return err
+			}
+		}
+
+		str.WriteRune(c)
+	default:
+		if r.opts.Syntax == JSON5 {
+			return readEscape5(r, b, str)
+		}
+		return r.syntaxErrorf("Invalid escape sequence \\%s in string", string(b))
+	}
+
+	return nil
+}
+
+// readSurrogate combines the high surrogate hi with the \uXXXX low
+// surrogate that must follow it.
+func readSurrogate(r *reader, hi rune) (rune, error) {
+	if hi >= 0xDC00 {
+		return utf8.RuneError, r.syntaxErrorf("Invalid escape sequence \\u%04X: unexpected low surrogate", hi)
+	}
+
+	d, err := r.Peek(2)
+	if err != nil || d[0] != '\\' || d[1] != 'u' {
+		return utf8.RuneError, r.syntaxErrorf("Invalid escape sequence \\u%04X: missing low surrogate", hi)
+	}
+	r.Discard(2)
+
+	lo, err := readHex4(r)
+	if err != nil {
+		return utf8.RuneError, err
+	}
+
+	c := utf16.DecodeRune(hi, lo)
+	if c == utf8.RuneError {
+		return c, r.syntaxErrorf("Invalid escape sequence \\u%04X\\u%04X: invalid surrogate pair", hi, lo)
+	}
+
+	return c, nil
+}
+
+// readHex4 reads the four hex digits of a \u escape.
+func readHex4(r *reader) (rune, error) {
+	d, err := r.Peek(4)
+	if err != nil {
+		if err == io.EOF {
+			return 0, r.eofError("Unexpected end of input in string")
+		}
+		return 0, err
+	}
+
+	var c rune
+	for _, h := range d {
+		switch {
+		case h >= '0' && h <= '9':
+			c = c<<4 | rune(h-'0')
+		case h >= 'a' && h <= 'f':
+			c = c<<4 | rune(h-'a'+10)
+		case h >= 'A' && h <= 'F':
+			c = c<<4 | rune(h-'A'+10)
+		default:
+			return 0, r.syntaxErrorf("Invalid escape sequence \\u%s in string", d)
+		}
+	}
+	r.Discard(4)
+
+	return c, nil
 }
@@ -23,16 +23,31 @@ const (
 	ArrayType  Type = 6
 
 	// special types
-	MemberType Type = 7
-	EndType    Type = 8
+	MemberType  Type = 7
+	EndType     Type = 8
+	CommentType Type = 9
 )
 
-// A Token is one of the primary objects
+// A Token is one of the primary objects. Tokens stay valid once read,
+// unless the decoder was created with ReuseTokens.
 type Token interface {
 	Type() Type
+
+	// Position returns where the token began in the input
+	Position() Position
+
+	// Path returns the path of the value from the top-level value. The
+	// value of a member has the path of the member, and an EndType
+	// token that of its object or array.
+	Path() []PathElement
+
+	// Pointer returns the path as a JSON Pointer, such as /orders/17
+	Pointer() string
 }
 
-// A SimpleToken is a token that can be unmarshalled into a pointer
+// A SimpleToken is a token that can be unmarshalled into a pointer.
+// Targets implementing TokenUnmarshaler, json.Unmarshaler or
+// encoding.TextUnmarshaler decode themselves.
 type SimpleToken interface {
 	Unmarshal(b interface{}) error
 }
@@ -40,4 +55,152 @@ type SimpleToken interface {
 // A ComplexToken is a token that is made up of other tokens
 type ComplexToken interface {
 	Next() (Token, error)
+
+	// Skip consumes the rest of the value without decoding it
+	Skip() error
+
+	// Raw consumes the value and returns its exact bytes
+	Raw() ([]byte, error)
+}
+
+// tokenInfo holds the metadata shared by all tokens
+type tokenInfo struct {
+	pos  Position
+	elem pathNode
+}
+
+// Position returns where the token began in the input
+func (ti tokenInfo) Position() Position {
+	return ti.pos
+}
+
+// Path returns the path of the value from the top-level value
+func (ti tokenInfo) Path() []PathElement {
+	return ti.elem.elements()
+}
+
+// Pointer returns the path as a JSON Pointer
+func (ti tokenInfo) Pointer() string {
+	return ti.elem.pointer()
+}
+
+func (ti tokenInfo) meta() tokenInfo {
+	return ti
+}
+
+// metaToken is implemented by all tokens created by the decoder
+type metaToken interface {
+	meta() tokenInfo
+}
+
+// metaOf returns the metadata of tok
+func metaOf(tok Token) tokenInfo {
+	if m, ok := tok.(metaToken); ok {
+		return m.meta()
+	}
+	return tokenInfo{pos: tok.Position()}
+}
+
+// A scalar holds a string, number, literal name, comment or end token.
+// The text is kept as read, often as a part of the buffered input, and
+// only turned into a string when asked for.
+type scalar struct {
+	tokenInfo
+	text []byte
+}
+
+// the text of the literal names
+var (
+	trueText  = []byte("true")
+	falseText = []byte("false")
+	nullText  = []byte("null")
+)
+
+// scalarOf returns the scalar of tok, or nil if tok has none
+func scalarOf(tok Token) *scalar {
+	switch t := tok.(type) {
+	case stringToken:
+		return t.scalar
+	case numberToken:
+		return t.scalar
+	case floatToken:
+		return t.scalar
+	case boolToken:
+		return t.scalar
+	case nullToken:
+		return t.scalar
+	case endToken:
+		return t.scalar
+	case commentToken:
+		return t.scalar
+	}
+	return nil
+}
+
+// withScalar returns a token of the same type as tok holding s
+func withScalar(tok Token, s *scalar) Token {
+	switch tok.(type) {
+	case stringToken:
+		return stringToken{s}
+	case numberToken:
+		return numberToken{s}
+	case floatToken:
+		return floatToken{s}
+	case boolToken:
+		return boolToken{s}
+	case nullToken:
+		return nullToken{s}
+	case endToken:
+		return endToken{s}
+	case commentToken:
+		return commentToken{s}
+	}
+	return tok
+}
+
+// withMeta returns a copy of the simple token tok carrying ti instead of
+// its own metadata
+func withMeta(tok Token, ti tokenInfo) Token {
+	if s := scalarOf(tok); s != nil {
+		return withScalar(tok, &scalar{tokenInfo: ti, text: s.text})
+	}
+	return tok
+}
+
+// detach returns a copy of tok that stays valid once the decoder reads
+// on. Objects and arrays are copied as if they had been read to the end.
+func detach(tok Token) Token {
+	switch t := tok.(type) {
+	case *MemberToken:
+		return &MemberToken{tokenInfo: t.detached(), Key: t.Key, Value: detach(t.Value)}
+	case objectToken:
+		return objectToken{&frame{tokenInfo: t.detached(), depth: t.depth, started: true, done: true}}
+	case arrayToken:
+		return arrayToken{&frame{tokenInfo: t.detached(), depth: t.depth, array: true, started: true, done: true}}
+	}
+	if s := scalarOf(tok); s != nil {
+		return withScalar(tok, &scalar{tokenInfo: s.detached(), text: append([]byte(nil), s.text...)})
+	}
+	return tok
+}
+
+// detached returns a copy of ti whose path no longer refers to the
+// frames of the decoder
+func (ti tokenInfo) detached() tokenInfo {
+	ti.elem = ti.elem.detached()
+	return ti
+}
+
+// toText returns the text of the simple token tok: the unescaped value
+// of a string, or the literal text of a number, bool or null
+func toText(tok Token) string {
+	return string(textOf(tok))
+}
+
+// textOf returns the text of tok like toText, without copying it
+func textOf(tok Token) []byte {
+	if s := scalarOf(tok); s != nil {
+		return s.text
+	}
+	return nil
 }
@@ -4,9 +4,9 @@ package sjson
 
 import "fmt"
 
-const _Type_name = "NumberTypeStringTypeBoolTypeNullTypeObjectTypeArrayTypeMemberTypeEndType"
+const _Type_name = "NumberTypeStringTypeBoolTypeNullTypeObjectTypeArrayTypeMemberTypeEndTypeCommentType"
 
-var _Type_index = [...]uint8{0, 10, 20, 28, 36, 46, 55, 65, 72}
+var _Type_index = [...]uint8{0, 10, 20, 28, 36, 46, 55, 65, 72, 83}
 
 func (i Type) String() string {
 	i -= 1
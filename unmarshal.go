@@ -0,0 +1,115 @@
+package sjson
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// A TokenUnmarshaler decodes itself from the token of a JSON value.
+// UnmarshalToken receives objects and arrays unread, so it can decode
+// their children as they are read; whatever it leaves unread is
+// skipped. Calling DecodeToken on the same type from UnmarshalToken
+// recurses, so decode into a type without the method instead.
+type TokenUnmarshaler interface {
+	UnmarshalToken(tok Token) error
+}
+
+var (
+	tokenUnmarshalerType = reflect.TypeOf((*TokenUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType  = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unmarshaler decodes tok into the addressable value v if it implements
+// TokenUnmarshaler, json.Unmarshaler or encoding.TextUnmarshaler, in
+// that order, and reports whether it did. Text unmarshalers only accept
+// strings, and leave null to the caller.
+func unmarshaler(tok Token, v reflect.Value) (bool, error) {
+	p := v.Addr()
+	switch pt := p.Type(); {
+	case pt.Implements(tokenUnmarshalerType):
+		if err := p.Interface().(TokenUnmarshaler).UnmarshalToken(tok); err != nil {
+			return true, err
+		}
+		return true, discard(tok)
+	case pt.Implements(jsonUnmarshalerType):
+		raw, err := rawJSON(tok)
+		if err != nil {
+			return true, err
+		}
+		return true, p.Interface().(json.Unmarshaler).UnmarshalJSON(raw)
+	case pt.Implements(textUnmarshalerType):
+		switch tok.Type() {
+		case StringType:
+			return true, p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(toText(tok)))
+		case NullType:
+			return false, nil
+		}
+		return true, metaOf(tok).typeError(describe(tok), v.Type())
+	}
+	return false, nil
+}
+
+// rawJSON returns the JSON text of tok, reading objects and arrays to
+// their end
+func rawJSON(tok Token) ([]byte, error) {
+	switch tok.Type() {
+	case ObjectType, ArrayType:
+		return tok.(ComplexToken).Raw()
+	case StringType:
+		return appendString(nil, toText(tok)), nil
+	}
+	return []byte(toText(tok)), nil
+}
+
+// unmarshalTarget returns the value pointed to by b, or an
+// UnmarshalTypeError if b is not a non-nil pointer
+func (ti tokenInfo) unmarshalTarget(b interface{}, value string) (reflect.Value, error) {
+	v := reflect.ValueOf(b)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, ti.typeError(value, reflect.TypeOf(b))
+	}
+
+	return v.Elem(), nil
+}
+
+// typeError creates an UnmarshalTypeError for storing the JSON value
+// described by value into a Go value of type t
+func (ti tokenInfo) typeError(value string, t reflect.Type) *UnmarshalTypeError {
+	return &UnmarshalTypeError{
+		Value:    value,
+		Type:     t,
+		Position: ti.pos,
+		Path:     ti.elem.pointer(),
+	}
+}
+
+// setNumber stores the JSON number text s into v
+func (ti tokenInfo) setNumber(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v.OverflowInt(i) {
+			return ti.typeError("number "+s, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || v.OverflowUint(u) {
+			return ti.typeError("number "+s, v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return ti.typeError("number "+s, v.Type())
+		}
+		v.SetFloat(f)
+	default:
+		return ti.typeError("number", v.Type())
+	}
+
+	return nil
+}